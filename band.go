@@ -0,0 +1,371 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+/*
+#include <gdal.h>
+#include <gdal_version.h>
+#include <stdlib.h>
+
+// deleteNoDataValue is GDALDeleteRasterNoDataValue (GDAL >= 2.1).
+static CPLErr deleteNoDataValue(GDALRasterBandH hBand) {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(2,1,0)
+	return GDALDeleteRasterNoDataValue(hBand);
+#else
+	CPLError(CE_Failure, CPLE_NotSupported, "GDALDeleteRasterNoDataValue() requires GDAL >= 2.1");
+	return CE_Failure;
+#endif
+}
+*/
+import "C"
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"unsafe"
+)
+
+type ColorInterp int
+
+const (
+	GCI_Undefined      ColorInterp = iota // "Undefined"
+	GCI_GrayIndex                         // "Gray"
+	GCI_PaletteIndex                      // "Palette"
+	GCI_RedBand                           // "Red"
+	GCI_GreenBand                         // "Green"
+	GCI_BlueBand                          // "Blue"
+	GCI_AlphaBand                         // "Alpha"
+	GCI_HueBand                           // "Hue"
+	GCI_SaturationBand                    // "Saturation"
+	GCI_LightnessBand                     // "Lightness"
+	GCI_CyanBand                          // "Cyan"
+	GCI_MagentaBand                       // "Magenta"
+	GCI_YellowBand                        // "Yellow"
+	GCI_BlackBand                         // "Black"
+	GCI_YCbCr_YBand                       // "YCbCr_Y"
+	GCI_YCbCr_CbBand                      // "YCbCr_Cb"
+	GCI_YCbCr_CrBand                      // "YCbCr_Cr"
+)
+
+func NewColorInterp(name string) ColorInterp {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return ColorInterp(C.GDALGetColorInterpretationByName(cname))
+}
+
+func (p ColorInterp) Name() string {
+	return C.GoString(C.GDALGetColorInterpretationName(C.GDALColorInterp(p)))
+}
+
+// RasterBand is a band of Dataset.
+//
+// The methods of RasterBand share the lock of the Dataset.
+type RasterBand struct {
+	ds     *Dataset
+	index  int
+	poBand C.GDALRasterBandH
+}
+
+// Band returns the i-th band of the dataset, i is in [0, Channels()).
+func (p *Dataset) Band(i int) (*RasterBand, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i < 0 || i >= p._Channels {
+		return nil, fmt.Errorf("gdal: Dataset(%q).Band(%d), index out of range.", p.Filename, i)
+	}
+	poBand := C.GDALGetRasterBand(p.poDataset, C.int(i+1))
+	if poBand == nil {
+		return nil, fmt.Errorf("gdal: Dataset(%q).Band(%d) failed.", p.Filename, i)
+	}
+	return &RasterBand{
+		ds:     p,
+		index:  i,
+		poBand: poBand,
+	}, nil
+}
+
+// Index returns the index of the band in the dataset.
+func (p *RasterBand) Index() int { return p.index }
+
+// Dataset returns the dataset which owns the band.
+func (p *RasterBand) Dataset() *Dataset { return p.ds }
+
+func (p *RasterBand) Width() int  { return p.ds._Width }
+func (p *RasterBand) Height() int { return p.ds._Height }
+
+func (p *RasterBand) DataType() reflect.Kind {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return goDataType(C.GDALGetRasterDataType(p.poBand))
+}
+
+// BlockSize returns the natural block size of the band.
+func (p *RasterBand) BlockSize() image.Point {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var nXSize, nYSize C.int
+	C.GDALGetBlockSize(p.poBand, &nXSize, &nYSize)
+	return image.Pt(int(nXSize), int(nYSize))
+}
+
+func (p *RasterBand) ColorInterpretation() ColorInterp {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return ColorInterp(C.GDALGetRasterColorInterpretation(p.poBand))
+}
+
+func (p *RasterBand) SetColorInterpretation(ci ColorInterp) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	if err := setColorInterp(p.poBand, ci); err != nil {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).SetColorInterpretation(%s) failed: %w", p.ds.Filename, p.index, ci.Name(), err)
+	}
+	return nil
+}
+
+func (p *RasterBand) Description() string {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return C.GoString(C.GDALGetDescription(C.GDALMajorObjectH(p.poBand)))
+}
+
+func (p *RasterBand) SetDescription(desc string) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	cdesc := C.CString(desc)
+	defer C.free(unsafe.Pointer(cdesc))
+
+	C.GDALSetDescription(C.GDALMajorObjectH(p.poBand), cdesc)
+	return nil
+}
+
+// Offset returns the raster value offset, ok is false if it is not set.
+//
+//	real_value = raw_value * Scale() + Offset()
+func (p *RasterBand) Offset() (offset float64, ok bool) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var bSuccess C.int
+	offset = float64(C.GDALGetRasterOffset(p.poBand, &bSuccess))
+	return offset, bSuccess != 0
+}
+
+func (p *RasterBand) SetOffset(offset float64) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetRasterOffset(p.poBand, C.double(offset))
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: RasterBand(%q, %d).SetOffset(%v) failed", p.ds.Filename, p.index, offset)
+	}
+	return nil
+}
+
+// Scale returns the raster value scale, ok is false if it is not set.
+func (p *RasterBand) Scale() (scale float64, ok bool) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var bSuccess C.int
+	scale = float64(C.GDALGetRasterScale(p.poBand, &bSuccess))
+	return scale, bSuccess != 0
+}
+
+func (p *RasterBand) SetScale(scale float64) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetRasterScale(p.poBand, C.double(scale))
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: RasterBand(%q, %d).SetScale(%v) failed", p.ds.Filename, p.index, scale)
+	}
+	return nil
+}
+
+// UnitType returns the name of the raster value units, e.g. "m" or "ft".
+func (p *RasterBand) UnitType() string {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return C.GoString(C.GDALGetRasterUnitType(p.poBand))
+}
+
+func (p *RasterBand) SetUnitType(unitType string) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	cUnitType := C.CString(unitType)
+	defer C.free(unsafe.Pointer(cUnitType))
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetRasterUnitType(p.poBand, cUnitType)
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: RasterBand(%q, %d).SetUnitType(%q) failed", p.ds.Filename, p.index, unitType)
+	}
+	return nil
+}
+
+// Read reads the r area of the band into data.
+//
+// data must be a slice of a supported pixel type (such as []uint8, []int16
+// or []float32) with at least r.Dx()*r.Dy() elements. GDAL converts the band
+// data type to the slice element type.
+func (p *RasterBand) Read(r image.Rectangle, data interface{}) error {
+	return p.ReadToSize(r, r.Size(), data)
+}
+
+// ReadToSize reads the r area of the band into data, resampled to size.
+func (p *RasterBand) ReadToSize(r image.Rectangle, size image.Point, data interface{}) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return p.rasterIO(C.GF_Read, r, size, data)
+}
+
+// ReadImage reads the r area of the band as a one channel image.
+func (p *RasterBand) ReadImage(r image.Rectangle) (m *MemPImage, err error) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	dataType := goDataType(C.GDALGetRasterDataType(p.poBand))
+	m = NewMemPImage(r, 1, dataType)
+	if err = p.rasterIOBuf(C.GF_Read, r, r.Size(), m.XPix, dataType); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Write writes data to the r area of the band.
+//
+// data must be a slice of a supported pixel type with at least
+// r.Dx()*r.Dy() elements.
+func (p *RasterBand) Write(r image.Rectangle, data interface{}) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return p.rasterIO(C.GF_Write, r, r.Size(), data)
+}
+
+func (p *RasterBand) rasterIO(eRWFlag C.GDALRWFlag, r image.Rectangle, size image.Point, data interface{}) error {
+	sv := reflect.ValueOf(data)
+	if sv.Kind() != reflect.Slice {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).rasterIO, data is not a slice: %T", p.ds.Filename, p.index, data)
+	}
+	return p.rasterIOBuf(eRWFlag, r, size, AsPixSlice(data), sv.Type().Elem().Kind())
+}
+
+func (p *RasterBand) rasterIOBuf(eRWFlag C.GDALRWFlag, r image.Rectangle, size image.Point, data []byte, dataType reflect.Kind) error {
+	eBufType := gdalDataType(dataType)
+	if eBufType == C.GDT_Unknown {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).rasterIO, unsupported data type: %v", p.ds.Filename, p.index, dataType)
+	}
+	if n := size.X * size.Y * SizeofKind(dataType); len(data) < n {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).rasterIO, buffer too small: %d < %d", p.ds.Filename, p.index, len(data), n)
+	}
+	if r.Empty() || size.X <= 0 || size.Y <= 0 {
+		return nil
+	}
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALRasterIO(p.poBand, eRWFlag,
+			C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Dx()), C.int(r.Dy()),
+			unsafe.Pointer(&data[0]), C.int(size.X), C.int(size.Y),
+			eBufType, 0, 0,
+		)
+	})
+	if cErr != C.CE_None {
+		op := "read"
+		if eRWFlag == C.GF_Write {
+			op = "write"
+		}
+		return cplErrorf(cplErr, "gdal: RasterBand(%q, %d).%s failed", p.ds.Filename, p.index, op)
+	}
+	return nil
+}
+
+// GetNoDataValue returns the NoData value of the band, ok is false if it is not set.
+func (p *RasterBand) GetNoDataValue() (nodata float64, ok bool) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return getNoDataValue(p.poBand)
+}
+
+func (p *RasterBand) SetNoDataValue(nodata float64) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	if err := setNoDataValue(p.poBand, nodata); err != nil {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).SetNoDataValue(%v) failed: %w", p.ds.Filename, p.index, nodata, err)
+	}
+	if p.index == 0 {
+		p.ds.Opt.NoData = &nodata
+	}
+	return nil
+}
+
+func (p *RasterBand) DeleteNoDataValue() error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	if err := deleteNoDataValue(p.poBand); err != nil {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).DeleteNoDataValue failed: %w", p.ds.Filename, p.index, err)
+	}
+	if p.index == 0 {
+		p.ds.Opt.NoData = nil
+	}
+	return nil
+}
+
+func getNoDataValue(poBand C.GDALRasterBandH) (nodata float64, ok bool) {
+	var bSuccess C.int
+	nodata = float64(C.GDALGetRasterNoDataValue(poBand, &bSuccess))
+	return nodata, bSuccess != 0
+}
+
+func setNoDataValue(poBand C.GDALRasterBandH, nodata float64) error {
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetRasterNoDataValue(poBand, C.double(nodata))
+	})
+	if cErr != C.CE_None {
+		if cplErr != nil {
+			return cplErr
+		}
+		return fmt.Errorf("gdal: GDALSetRasterNoDataValue(%v) failed.", nodata)
+	}
+	return nil
+}
+
+func deleteNoDataValue(poBand C.GDALRasterBandH) error {
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.deleteNoDataValue(poBand)
+	})
+	if cErr != C.CE_None {
+		if cplErr != nil {
+			return cplErr
+		}
+		return fmt.Errorf("gdal: GDALDeleteRasterNoDataValue failed.")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"errors"
+	"image"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRasterBand_Read(t *testing.T) {
+	m, err := LoadImage("./testdata/video-001.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenDataset("./testdata/video-001.tiff", GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Band(f.Channels()); err == nil {
+		t.Fatal("expect out of range error")
+	}
+
+	for i := 0; i < f.Channels(); i++ {
+		band, err := f.Band(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if band.DataType() != reflect.Uint8 {
+			t.Fatalf("band %d: bad data type %v", i, band.DataType())
+		}
+
+		r := image.Rect(0, 0, f.Width(), f.Height())
+		data := make([]float32, r.Dx()*r.Dy())
+		if err := band.Read(r, data); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < r.Dy(); y++ {
+			for x := 0; x < r.Dx(); x++ {
+				if v0, v1 := float32(m.PixelAt(x, y)[i]), data[y*r.Dx()+x]; v0 != v1 {
+					t.Fatalf("band %d: (%d, %d): expect = %v, got = %v", i, x, y, v0, v1)
+				}
+			}
+		}
+	}
+}
+
+func TestRasterBand_Statistics(t *testing.T) {
+	tmpname := "z_test_TestRasterBand_Statistics.tiff"
+	defer os.Remove(tmpname)
+	defer os.Remove(tmpname + ".aux.xml")
+
+	m, err := Load("./testdata/video-001-gray.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(tmpname, m, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenDataset(tmpname, GA_Update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	band, err := f.Band(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := band.Statistics(false, false); !errors.Is(err, ErrNoStatistics) {
+		t.Fatalf("expect ErrNoStatistics, got = %v", err)
+	}
+	st, err := band.ComputeStatistics(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Min > st.Mean || st.Mean > st.Max || st.StdDev < 0 {
+		t.Fatalf("bad statistics: %v", st)
+	}
+	if cached, err := band.Statistics(false, false); err != nil || cached != st {
+		t.Fatalf("cached statistics: expect = %v, got = %v, %v", st, cached, err)
+	}
+
+	// the statistics are saved
+	f.Close()
+	if f, err = OpenDataset(tmpname, GA_ReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if band, err = f.Band(0); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := band.Statistics(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the metadata keeps about 14 significant digits
+	if math.Abs(saved.Min-st.Min) > 1e-9 || math.Abs(saved.Max-st.Max) > 1e-9 ||
+		math.Abs(saved.Mean-st.Mean) > 1e-9 || math.Abs(saved.StdDev-st.StdDev) > 1e-9 {
+		t.Fatalf("saved statistics: expect = %v, got = %v", st, saved)
+	}
+
+	h, err := band.Histogram(&HistogramOptions{Min: -0.5, Max: 255.5, Buckets: 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total uint64
+	for _, v := range h.Buckets {
+		total += v
+	}
+	if n := uint64(f.Width() * f.Height()); total != n {
+		t.Fatalf("histogram total: expect = %d, got = %d", n, total)
+	}
+}
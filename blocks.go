@@ -0,0 +1,213 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+import "C"
+import (
+	"fmt"
+	"image"
+	"sync"
+)
+
+// BlocksOptions are the options of Dataset.BlocksWithOptions.
+//
+// Size is rounded up to a multiple of the block size, the zero Size means
+// the block size, enlarged to at least 256x256 (such as 1 line strips).
+type BlocksOptions struct {
+	Rect     image.Rectangle // region to walk, empty means the whole raster
+	Size     image.Point     // window size
+	Parallel int             // number of goroutines calling fn, <= 1 means fn is called in the caller goroutine
+}
+
+// BlockSize returns the natural block size of the first band.
+func (p *Dataset) BlockSize() image.Point {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.blockSize()
+}
+
+func (p *Dataset) blockSize() image.Point {
+	if p._Channels == 0 {
+		return image.Point{}
+	}
+	var nXSize, nYSize C.int
+	C.GDALGetBlockSize(C.GDALGetRasterBand(p.poDataset, 1), &nXSize, &nYSize)
+	return image.Pt(int(nXSize), int(nYSize))
+}
+
+// Blocks is same as BlocksWithOptions with the default options.
+func (p *Dataset) Blocks(fn func(r image.Rectangle, m *MemPImage) error) error {
+	return p.BlocksWithOptions(nil, fn)
+}
+
+// BlocksWithOptions walks the raster in block-aligned windows, from left
+// to right and top to bottom, and calls fn with the window and its pixels.
+//
+// The pixel buffers are reused, m is only valid until fn returns.
+// The reading is serialized by the Dataset lock, only fn is called in
+// parallel if opt.Parallel > 1. The walking stops at the first error.
+func (p *Dataset) BlocksWithOptions(opt *BlocksOptions, fn func(r image.Rectangle, m *MemPImage) error) error {
+	if opt == nil {
+		opt = new(BlocksOptions)
+	}
+
+	p.mu.Lock()
+	bounds := image.Rect(0, 0, p._Width, p._Height)
+	blockSize := p.blockSize()
+	channels, dataType := p._Channels, p._DataType
+	var nodata *float64
+	if p.Opt.NoData != nil {
+		v := *p.Opt.NoData
+		nodata = &v
+	}
+	p.mu.Unlock()
+
+	if channels == 0 || blockSize.X <= 0 || blockSize.Y <= 0 {
+		return fmt.Errorf("gdal: Dataset(%q).Blocks, no bands.", p.Filename)
+	}
+
+	rect := bounds
+	if !opt.Rect.Empty() {
+		rect = opt.Rect.Intersect(bounds)
+	}
+	if rect.Empty() {
+		return nil
+	}
+	size := blocksWindowSize(blockSize, opt.Size, rect)
+
+	n := opt.Parallel
+	if n < 1 {
+		n = 1
+	}
+	pool := make(chan *MemPImage, n)
+	for i := 0; i < n; i++ {
+		pool <- &MemPImage{
+			XMemPMagic: MemPMagic,
+			XChannels:  channels,
+			XDataType:  dataType,
+			XPix:       make([]byte, size.X*size.Y*SizeofPixel(channels, dataType)),
+			XNoData:    nodata,
+		}
+	}
+
+	if n == 1 {
+		m := <-pool
+		var err error
+		walkBlockWindows(rect, size, func(r image.Rectangle) bool {
+			if err = p.readBlock(r, m); err != nil {
+				return false
+			}
+			err = fn(r, m)
+			return err == nil
+		})
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		done     = make(chan struct{})
+		jobs     = make(chan *MemPImage)
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(done)
+		})
+	}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range jobs {
+				if err := fn(m.XRect, m); err != nil {
+					setErr(err)
+				}
+				pool <- m
+			}
+		}()
+	}
+
+	walkBlockWindows(rect, size, func(r image.Rectangle) bool {
+		var m *MemPImage
+		select {
+		case m = <-pool:
+		case <-done:
+			return false
+		}
+		if err := p.readBlock(r, m); err != nil {
+			setErr(err)
+			pool <- m
+			return false
+		}
+		select {
+		case jobs <- m:
+			return true
+		case <-done:
+			pool <- m
+			return false
+		}
+	})
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
+// readBlock reads the window r into m, the buffer of m is reused.
+func (p *Dataset) readBlock(r image.Rectangle, m *MemPImage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.poDataset == nil {
+		return fmt.Errorf("gdal: Dataset(%q).Blocks, dataset closed.", p.Filename)
+	}
+
+	m.XRect = r
+	m.XStride = r.Dx() * SizeofPixel(m.XChannels, m.XDataType)
+	m.XPix = m.XPix[:cap(m.XPix)][:r.Dy()*m.XStride]
+	return p.readWithSize(r, r.Dx(), r.Dy(), m.XPix, m.XStride, p._DataType, nil)
+}
+
+// blocksWindowSize returns the window size in multiples of the block size.
+func blocksWindowSize(blockSize, size image.Point, rect image.Rectangle) image.Point {
+	const minSize = 256
+
+	if size.X <= 0 {
+		size.X = minSize
+	}
+	if size.Y <= 0 {
+		size.Y = minSize
+	}
+	size.X = (size.X + blockSize.X - 1) / blockSize.X * blockSize.X
+	size.Y = (size.Y + blockSize.Y - 1) / blockSize.Y * blockSize.Y
+
+	// the aligned windows may cover at most rect plus one block
+	if max := rect.Dx() + blockSize.X; size.X > max {
+		size.X = (max + blockSize.X - 1) / blockSize.X * blockSize.X
+	}
+	if max := rect.Dy() + blockSize.Y; size.Y > max {
+		size.Y = (max + blockSize.Y - 1) / blockSize.Y * blockSize.Y
+	}
+	return size
+}
+
+// walkBlockWindows calls visit with the windows of size (aligned to the
+// origin of the raster) intersecting rect, until visit returns false.
+func walkBlockWindows(rect image.Rectangle, size image.Point, visit func(r image.Rectangle) bool) {
+	y0 := rect.Min.Y / size.Y * size.Y
+	x0 := rect.Min.X / size.X * size.X
+	for y := y0; y < rect.Max.Y; y += size.Y {
+		for x := x0; x < rect.Max.X; x += size.X {
+			r := image.Rect(x, y, x+size.X, y+size.Y).Intersect(rect)
+			if !visit(r) {
+				return
+			}
+		}
+	}
+}
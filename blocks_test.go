@@ -0,0 +1,75 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image"
+	"sync"
+	"testing"
+)
+
+func TestDataset_Blocks(t *testing.T) {
+	f, err := OpenDataset("./testdata/video-001-tile-64x64.tiff", GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if v := f.BlockSize(); v != image.Pt(64, 64) {
+		t.Fatalf("expect = %v, got = %v", image.Pt(64, 64), v)
+	}
+
+	for _, parallel := range []int{0, 4} {
+		var (
+			mu    sync.Mutex
+			area  int
+			fails []image.Rectangle
+		)
+		opt := &BlocksOptions{Size: image.Pt(64, 64), Parallel: parallel}
+		err := f.BlocksWithOptions(opt, func(r image.Rectangle, m *MemPImage) error {
+			if r.Min.X%64 != 0 || r.Min.Y%64 != 0 || r.Dx() > 64 || r.Dy() > 64 {
+				t.Errorf("bad window: %v", r)
+			}
+			expect, err := f.Read(r)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			area += r.Dx() * r.Dy()
+			if !bytes.Equal(expect.(*MemPImage).XPix, m.XPix) {
+				fails = append(fails, r)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if area != f.Width()*f.Height() {
+			t.Fatalf("parallel = %d: expect area = %d, got = %d", parallel, f.Width()*f.Height(), area)
+		}
+		if len(fails) != 0 {
+			t.Fatalf("parallel = %d: pixels not equal: %v", parallel, fails)
+		}
+	}
+}
+
+func TestBlocksWindowSize(t *testing.T) {
+	rect := image.Rect(0, 0, 100000, 100000)
+	for i, v := range []struct {
+		blockSize, size, expect image.Point
+	}{
+		{image.Pt(256, 256), image.Point{}, image.Pt(256, 256)},
+		{image.Pt(512, 512), image.Point{}, image.Pt(512, 512)},
+		{image.Pt(100000, 1), image.Point{}, image.Pt(100000, 256)},
+		{image.Pt(64, 64), image.Pt(100, 100), image.Pt(128, 128)},
+	} {
+		if got := blocksWindowSize(v.blockSize, v.size, rect); got != v.expect {
+			t.Fatalf("%d: expect = %v, got = %v", i, v.expect, got)
+		}
+	}
+}
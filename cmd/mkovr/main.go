@@ -13,7 +13,8 @@
 //	  mkovr filename GAUSS
 //	  mkovr filename AVERAGE
 //
-//	ResampleType: NONE|NEAREST|GAUSS|CUBIC|AVERAGE|MODE|AVERAGE_MAGPHASE.
+//	ResampleType: NONE|NEAREST|GAUSS|CUBIC|AVERAGE|MODE|AVERAGE_MAGPHASE|
+//	              BILINEAR|CUBICSPLINE|LANCZOS.
 //
 //	Report bugs to <chaishushan{AT}gmail.com>.
 //
@@ -36,7 +37,8 @@ Example:
   mkovr filename GAUSS
   mkovr filename AVERAGE
 
-ResampleType: NONE|NEAREST|GAUSS|CUBIC|AVERAGE|MODE|AVERAGE_MAGPHASE.
+ResampleType: NONE|NEAREST|GAUSS|CUBIC|AVERAGE|MODE|AVERAGE_MAGPHASE|
+              BILINEAR|CUBICSPLINE|LANCZOS.
 
 Report bugs to <chaishushan{AT}gmail.com>.
 `
@@ -0,0 +1,121 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Make the tile pyramid of a raster file (like gdal2tiles).
+//
+//	Usage: mktiles [options] filename output
+//	       mktiles -h
+//
+//	Example:
+//	  mktiles world.tiff tiles
+//	  mktiles -zoom=0-8 -format=jpg world.tiff tiles
+//	  mktiles -tiling=raster scan.tiff tiles
+//	  mktiles world.tiff world.mbtiles
+//
+//	The output is a {z}/{x}/{y} directory, or a MBTiles file if it has the
+//	".mbtiles" extension (mercator tiling only).
+//
+//	Report bugs to <chaishushan{AT}gmail.com>.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/chai2010/gdal"
+	"github.com/chai2010/gdal/tileserver"
+)
+
+const usage = `
+Usage: mktiles [options] filename output
+       mktiles -h
+
+Example:
+  mktiles world.tiff tiles
+  mktiles -zoom=0-8 -format=jpg world.tiff tiles
+  mktiles -tiling=raster scan.tiff tiles
+  mktiles world.tiff world.mbtiles
+
+The output is a {z}/{x}/{y} directory, or a MBTiles file if it has the
+".mbtiles" extension (mercator tiling only).
+
+Options:
+`
+
+var (
+	flagTiling   = flag.String("tiling", "mercator", "tiling: mercator|raster")
+	flagZoom     = flag.String("zoom", "", "zoom range: min-max, max is the native zoom if omitted, such as '0-' or '2-8'")
+	flagFormat   = flag.String("format", "png", "tile format: png|jpg")
+	flagSize     = flag.Int("size", 256, "tile size")
+	flagResample = flag.String("r", "", "ResampleType: NEAREST|BILINEAR|CUBIC|AVERAGE|...")
+	flagQuality  = flag.Int("quality", 75, "jpeg quality")
+	flagJobs     = flag.Int("j", runtime.NumCPU(), "number of the rendering goroutines")
+	flagTMS      = flag.Bool("tms", false, "y origin at the bottom (TMS), only for the directory output")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage[1:])
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nReport bugs to <chaishushan{AT}gmail.com>.")
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	filename, output := flag.Arg(0), flag.Arg(1)
+
+	minZoom, maxZoom, err := parseZoomRange(*flagZoom)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = tileserver.GeneratePyramid(filename, output, &tileserver.PyramidOptions{
+		Tiling:       tileserver.NewTiling(*flagTiling),
+		MinZoom:      minZoom,
+		MaxZoom:      maxZoom,
+		Format:       *flagFormat,
+		TileSize:     *flagSize,
+		ResampleType: gdal.NewResampleType(*flagResample),
+		JPEGQuality:  *flagQuality,
+		Concurrency:  *flagJobs,
+		TMS:          *flagTMS,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseZoomRange parses "min-max", "min-" or "zoom", nil maxZoom means the
+// native zoom.
+func parseZoomRange(s string) (minZoom int, maxZoom *int, err error) {
+	if s == "" {
+		return 0, nil, nil
+	}
+	ss := strings.SplitN(s, "-", 2)
+	if len(ss) == 1 {
+		if minZoom, err = strconv.Atoi(ss[0]); err != nil {
+			return 0, nil, fmt.Errorf("mktiles: bad zoom range: %q", s)
+		}
+		return minZoom, &minZoom, nil
+	}
+	if minZoom, err = strconv.Atoi(ss[0]); err != nil {
+		return 0, nil, fmt.Errorf("mktiles: bad zoom range: %q", s)
+	}
+	if ss[1] == "" {
+		return minZoom, nil, nil
+	}
+	z, err := strconv.Atoi(ss[1])
+	if err != nil {
+		return 0, nil, fmt.Errorf("mktiles: bad zoom range: %q", s)
+	}
+	return minZoom, &z, nil
+}
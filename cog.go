@@ -0,0 +1,325 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+//#include <cpl_string.h>
+//#include <stdlib.h>
+import "C"
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// COGOptions are the options of CreateCOG and SaveCOG.
+//
+// Opt is only used by SaveCOG, for the Projection, Transform and NoData
+// of the output.
+type COGOptions struct {
+	Compress     string            // "DEFLATE"(default), "LZW", "JPEG", "WEBP", "ZSTD", "NONE", ...
+	Quality      int               // JPEG/WEBP quality, 0 means default
+	Predictor    bool              // use the horizontal (or floating point) predictor
+	BlockSize    int               // tile size, 0 means 512
+	ResampleType ResampleType      // overview resampling, ResampleType_Nil means default
+	Opt          *Options          // only for SaveCOG
+	ExtOptions   map[string]string // other creation options of the output driver
+}
+
+const cogDefaultBlockSize = 512
+
+// CreateCOG writes the src dataset to filename as Cloud Optimized GeoTIFF.
+//
+// The COG driver (GDAL >= 3.1) is used if available. Otherwise the src is
+// copied to a tiled temporary GeoTIFF, the internal overviews are built,
+// and then copied to filename with COPY_SRC_OVERVIEWS=YES.
+func CreateCOG(filename string, src *Dataset, opt *COGOptions) error {
+	if opt == nil {
+		opt = new(COGOptions)
+	}
+	if !opt.ResampleType.isOverviewResampling() {
+		return fmt.Errorf("gdal: CreateCOG(%q), unsupported overview resample type: %s", filename, opt.ResampleType.Name())
+	}
+
+	cDriverName := C.CString("COG")
+	defer C.free(unsafe.Pointer(cDriverName))
+
+	if C.GDALGetDriverByName(cDriverName) != nil {
+		p, err := CreateDatasetCopy(filename, src, &Options{
+			DriverName: "COG",
+			ExtOptions: opt.cogCreationOptions(),
+		})
+		if err != nil {
+			return fmt.Errorf("gdal: CreateCOG(%q) failed: %w", filename, err)
+		}
+		return p.Close()
+	}
+
+	if err := createCOGWithGTiff(filename, src, opt); err != nil {
+		return fmt.Errorf("gdal: CreateCOG(%q) failed: %w", filename, err)
+	}
+	return nil
+}
+
+// SaveCOG writes the image m to filename as Cloud Optimized GeoTIFF.
+func SaveCOG(filename string, m image.Image, opt *COGOptions) error {
+	if opt == nil {
+		opt = new(COGOptions)
+	}
+	src, err := newMemDatasetFrom(m, opt.Opt)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return CreateCOG(filename, src, opt)
+}
+
+func createCOGWithGTiff(filename string, src *Dataset, opt *COGOptions) error {
+	f, err := ioutil.TempFile("", "gdal-cog-*.tif")
+	if err != nil {
+		return err
+	}
+	tmpname := f.Name()
+	f.Close()
+	defer os.Remove(tmpname)
+	defer os.Remove(tmpname + ".aux.xml")
+
+	blockSize := opt.blockSize()
+	tmp, err := CreateDatasetCopy(tmpname, src, &Options{
+		DriverName: "GTiff",
+		ExtOptions: map[string]string{
+			"TILED":      "YES",
+			"BLOCKXSIZE": strconv.Itoa(blockSize),
+			"BLOCKYSIZE": strconv.Itoa(blockSize),
+			"BIGTIFF":    "IF_SAFER",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+
+	tmp.mu.Lock()
+	tmp.resampleType = opt.ResampleType
+	err = tmp.buildOverviews(cogOverviewList(tmp._Width, tmp._Height, blockSize), nil)
+	tmp.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	p, err := CreateDatasetCopy(filename, tmp, &Options{
+		DriverName: "GTiff",
+		ExtOptions: opt.gtiffCreationOptions(src._DataType),
+	})
+	if err != nil {
+		return err
+	}
+	return p.Close()
+}
+
+func (opt *COGOptions) blockSize() int {
+	if opt.BlockSize > 0 {
+		return opt.BlockSize
+	}
+	return cogDefaultBlockSize
+}
+
+func (opt *COGOptions) compress() string {
+	if opt.Compress != "" {
+		return strings.ToUpper(opt.Compress)
+	}
+	return "DEFLATE"
+}
+
+// cogCreationOptions returns the creation options of the COG driver.
+func (opt *COGOptions) cogCreationOptions() map[string]string {
+	m := map[string]string{
+		"COMPRESS":  opt.compress(),
+		"BLOCKSIZE": strconv.Itoa(opt.blockSize()),
+		"BIGTIFF":   "IF_SAFER",
+	}
+	if opt.Quality > 0 {
+		m["QUALITY"] = strconv.Itoa(opt.Quality)
+	}
+	if opt.Predictor {
+		m["PREDICTOR"] = "YES"
+	}
+	if opt.ResampleType != ResampleType_Nil {
+		m["RESAMPLING"] = opt.ResampleType.warpName()
+	}
+	for k, v := range opt.ExtOptions {
+		m[k] = v
+	}
+	return m
+}
+
+// gtiffCreationOptions returns the creation options of the GTiff driver,
+// which are same as the COG driver.
+func (opt *COGOptions) gtiffCreationOptions(dataType reflect.Kind) map[string]string {
+	m := map[string]string{
+		"TILED":              "YES",
+		"BLOCKXSIZE":         strconv.Itoa(opt.blockSize()),
+		"BLOCKYSIZE":         strconv.Itoa(opt.blockSize()),
+		"COMPRESS":           opt.compress(),
+		"COPY_SRC_OVERVIEWS": "YES",
+		"BIGTIFF":            "IF_SAFER",
+	}
+	if opt.Quality > 0 {
+		switch opt.compress() {
+		case "JPEG":
+			m["JPEG_QUALITY"] = strconv.Itoa(opt.Quality)
+		case "WEBP":
+			m["WEBP_LEVEL"] = strconv.Itoa(opt.Quality)
+		}
+	}
+	if opt.Predictor {
+		if dataType == reflect.Float32 || dataType == reflect.Float64 {
+			m["PREDICTOR"] = "3"
+		} else {
+			m["PREDICTOR"] = "2"
+		}
+	}
+	for k, v := range opt.ExtOptions {
+		m[k] = v
+	}
+	return m
+}
+
+// cogOverviewList returns []int{2, 4, 8, ...}, until the overview fits in one tile.
+func cogOverviewList(width, height, blockSize int) []int {
+	maxImageSize := width
+	if maxImageSize < height {
+		maxImageSize = height
+	}
+
+	var overviewList []int
+	for factor, size := 1, maxImageSize; size > blockSize; size = (maxImageSize + factor - 1) / factor {
+		factor *= 2
+		overviewList = append(overviewList, factor)
+	}
+	return overviewList
+}
+
+// ValidateCOG checks that filename is a Cloud Optimized GeoTIFF:
+// the full resolution image and the overviews are tiled and internal, the
+// image larger than 512 pixels has overviews, the IFDs are placed before
+// the image data, and the image data of the smaller overviews are placed
+// before the bigger ones.
+//
+// The TIFF metadata domain (IFD_OFFSET, BLOCK_OFFSET_0_0) of GDAL >= 2.3 is
+// required.
+func ValidateCOG(filename string) error {
+	f, err := OpenDataset(filename, GA_ReadOnly)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Opt.DriverName != "GTiff" {
+		return fmt.Errorf("gdal: ValidateCOG(%q), not a GeoTIFF file (%s).", filename, f.Opt.DriverName)
+	}
+	if f._Channels == 0 {
+		return fmt.Errorf("gdal: ValidateCOG(%q), no bands.", filename)
+	}
+
+	var errs []string
+
+	papszFileList := C.GDALGetFileList(f.poDataset)
+	for _, s := range goStringList(papszFileList) {
+		if strings.EqualFold(filepath.Ext(s), ".ovr") {
+			errs = append(errs, "overviews found in external .ovr file")
+		}
+	}
+	C.CSLDestroy(papszFileList)
+
+	hBand := C.GDALGetRasterBand(f.poDataset, 1)
+	bands := []C.GDALRasterBandH{hBand}
+	for i := 0; i < int(C.GDALGetOverviewCount(hBand)); i++ {
+		bands = append(bands, C.GDALGetOverview(hBand, C.int(i)))
+	}
+
+	if len(bands) == 1 && (f._Width > cogDefaultBlockSize || f._Height > cogDefaultBlockSize) {
+		errs = append(errs, fmt.Sprintf("no overviews for the image larger than %d pixels", cogDefaultBlockSize))
+	}
+
+	ifdOffsets := make([]int64, len(bands))
+	dataOffsets := make([]int64, len(bands))
+	for i, hBand := range bands {
+		name := cogLevelName(i)
+
+		var nBlockXSize, nBlockYSize C.int
+		C.GDALGetBlockSize(hBand, &nBlockXSize, &nBlockYSize)
+		width := int(C.GDALGetRasterBandXSize(hBand))
+		if int(nBlockXSize) == width && width > 1024 {
+			errs = append(errs, fmt.Sprintf("%s is not tiled", name))
+		}
+
+		ifdOffset, ok := cogOffset(hBand, "IFD_OFFSET")
+		if !ok {
+			return fmt.Errorf("gdal: ValidateCOG(%q), missing IFD_OFFSET of %s, GDAL >= 2.3 required.", filename, name)
+		}
+		dataOffset, _ := cogOffset(hBand, "BLOCK_OFFSET_0_0")
+		ifdOffsets[i], dataOffsets[i] = ifdOffset, dataOffset
+	}
+
+	for i := 1; i < len(bands); i++ {
+		if ifdOffsets[i] < ifdOffsets[i-1] {
+			errs = append(errs, fmt.Sprintf(
+				"IFD of %s (%d) should be after the IFD of %s (%d)",
+				cogLevelName(i), ifdOffsets[i], cogLevelName(i-1), ifdOffsets[i-1],
+			))
+		}
+	}
+	for i := 0; i < len(bands); i++ {
+		if dataOffsets[i] != 0 && dataOffsets[i] < ifdOffsets[len(ifdOffsets)-1] {
+			errs = append(errs, fmt.Sprintf(
+				"data of %s (%d) should be after all the IFDs (%d)",
+				cogLevelName(i), dataOffsets[i], ifdOffsets[len(ifdOffsets)-1],
+			))
+		}
+	}
+	for i := 0; i+1 < len(bands); i++ {
+		if dataOffsets[i] != 0 && dataOffsets[i+1] != 0 && dataOffsets[i] < dataOffsets[i+1] {
+			errs = append(errs, fmt.Sprintf(
+				"data of %s (%d) should be after the data of %s (%d)",
+				cogLevelName(i), dataOffsets[i], cogLevelName(i+1), dataOffsets[i+1],
+			))
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("gdal: ValidateCOG(%q), not a COG: %s.", filename, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func cogLevelName(i int) string {
+	if i == 0 {
+		return "main image"
+	}
+	return fmt.Sprintf("overview %d", i-1)
+}
+
+func cogOffset(hBand C.GDALRasterBandH, name string) (int64, bool) {
+	s, ok := getMetadataItem(C.GDALMajorObjectH(hBand), name, "TIFF")
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
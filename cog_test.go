@@ -0,0 +1,89 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"image"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSaveCOG(t *testing.T) {
+	tmpname := "z_test_TestSaveCOG.tiff"
+	defer os.Remove(tmpname)
+
+	m := NewMemPImage(image.Rect(0, 0, 1200, 1000), 1, reflect.Uint8)
+	for i := range m.XPix {
+		m.XPix[i] = uint8(i)
+	}
+	if err := SaveCOG(tmpname, m, &COGOptions{BlockSize: 256}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateCOG(tmpname); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenDataset(tmpname, GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if !f.HasOverviews() {
+		t.Fatal("expect overviews")
+	}
+	band, err := f.Band(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := band.BlockSize(); v != image.Pt(256, 256) {
+		t.Fatalf("expect = %v, got = %v", image.Pt(256, 256), v)
+	}
+}
+
+func TestValidateCOG_stripped(t *testing.T) {
+	tmpname := "z_test_TestValidateCOG_stripped.tiff"
+	defer os.Remove(tmpname)
+
+	m := NewMemPImage(image.Rect(0, 0, 1200, 1000), 1, reflect.Uint8)
+	if err := Save(tmpname, m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateCOG(tmpname); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestValidateCOG_noOverviews(t *testing.T) {
+	tmpname := "z_test_TestValidateCOG_noOverviews.tiff"
+	defer os.Remove(tmpname)
+
+	m := NewMemPImage(image.Rect(0, 0, 1200, 1000), 1, reflect.Uint8)
+	if err := Save(tmpname, m, &Options{
+		ExtOptions: map[string]string{"TILED": "YES"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateCOG(tmpname); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestCogOverviewList(t *testing.T) {
+	for i, v := range []struct {
+		width, height, blockSize int
+		expect                   []int
+	}{
+		{512, 512, 512, nil},
+		{513, 100, 512, []int{2}},
+		{1200, 1000, 256, []int{2, 4, 8}},
+	} {
+		got := cogOverviewList(v.width, v.height, v.blockSize)
+		if !reflect.DeepEqual(got, v.expect) {
+			t.Fatalf("%d: expect = %v, got = %v", i, v.expect, got)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+import "C"
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+)
+
+// ColorInterpretations returns the color interpretations of the bands.
+func (p *Dataset) ColorInterpretations() []ColorInterp {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.colorInterps()
+}
+
+// SetColorInterpretations sets the color interpretations of the bands,
+// such as (GCI_BlueBand, GCI_GreenBand, GCI_RedBand) for BGR data.
+func (p *Dataset) SetColorInterpretations(list ...ColorInterp) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(list) != p._Channels {
+		return fmt.Errorf("gdal: Dataset(%q).SetColorInterpretations, expect %d values, got %d.", p.Filename, p._Channels, len(list))
+	}
+	for i, ci := range list {
+		if err := setColorInterp(C.GDALGetRasterBand(p.poDataset, C.int(i+1)), ci); err != nil {
+			return fmt.Errorf("gdal: Dataset(%q).SetColorInterpretations, band %d (%s) failed: %w", p.Filename, i, ci.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (p *Dataset) colorInterps() []ColorInterp {
+	list := make([]ColorInterp, p._Channels)
+	for i := range list {
+		list[i] = ColorInterp(C.GDALGetRasterColorInterpretation(C.GDALGetRasterBand(p.poDataset, C.int(i+1))))
+	}
+	return list
+}
+
+// setDefaultColorInterps sets the color interpretations of the channels of
+// a Go image (gray, gray+alpha, RGB or RGBA), the drivers which don't
+// support it are ignored.
+func (p *Dataset) setDefaultColorInterps() {
+	var list []ColorInterp
+	switch p._Channels {
+	case 1:
+		list = []ColorInterp{GCI_GrayIndex}
+	case 2:
+		list = []ColorInterp{GCI_GrayIndex, GCI_AlphaBand}
+	case 3:
+		list = []ColorInterp{GCI_RedBand, GCI_GreenBand, GCI_BlueBand}
+	case 4:
+		list = []ColorInterp{GCI_RedBand, GCI_GreenBand, GCI_BlueBand, GCI_AlphaBand}
+	}
+	for i, ci := range list {
+		poBand := C.GDALGetRasterBand(p.poDataset, C.int(i+1))
+		if ColorInterp(C.GDALGetRasterColorInterpretation(poBand)) != ci {
+			setColorInterp(poBand, ci)
+		}
+	}
+}
+
+func setColorInterp(poBand C.GDALRasterBandH, ci ColorInterp) error {
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetRasterColorInterpretation(poBand, C.GDALColorInterp(ci))
+	})
+	if cErr != C.CE_None {
+		if cplErr != nil {
+			return cplErr
+		}
+		return fmt.Errorf("gdal: GDALSetRasterColorInterpretation(%s) failed.", ci.Name())
+	}
+	return nil
+}
+
+// StdImageWithColorInterp is like StdImage, but the channels are mapped by
+// the color interpretations of the bands (such as BGR, alpha in the first
+// band, YCbCr, gray+alpha).
+//
+// The 8-bit and 16-bit color images are returned as *image.RGBA and
+// *image.RGBA64, the alpha band of GDAL is unassociated and is
+// premultiplied into the colors. If the color interpretations are unknown,
+// some bands are not mapped (such as RGB+NIR), or same as the channel order
+// of StdImage, StdImage is returned.
+func (p *MemPImage) StdImageWithColorInterp(interps []ColorInterp) image.Image {
+	if m, ok := p.mapColorInterp(interps); ok {
+		return m
+	}
+	return p.StdImage()
+}
+
+func (p *MemPImage) mapColorInterp(interps []ColorInterp) (m image.Image, ok bool) {
+	if p.XDataType != reflect.Uint8 && p.XDataType != reflect.Uint16 {
+		return nil, false
+	}
+	if len(interps) != p.XChannels {
+		return nil, false
+	}
+
+	idx := map[ColorInterp]int{}
+	for i, ci := range interps {
+		if _, ok := idx[ci]; !ok {
+			idx[ci] = i
+		}
+	}
+	index := func(ci ColorInterp) int {
+		if i, ok := idx[ci]; ok {
+			return i
+		}
+		return -1
+	}
+	iGray, iAlpha := index(GCI_GrayIndex), index(GCI_AlphaBand)
+	iR, iG, iB := index(GCI_RedBand), index(GCI_GreenBand), index(GCI_BlueBand)
+	iY, iCb, iCr := index(GCI_YCbCr_YBand), index(GCI_YCbCr_CbBand), index(GCI_YCbCr_CrBand)
+
+	const (
+		modeGray = iota
+		modeRGB
+		modeYCbCr
+	)
+	var mode int
+	switch {
+	case iR >= 0 && iG >= 0 && iB >= 0:
+		mode = modeRGB
+	case iY >= 0 && iCb >= 0 && iCr >= 0 && p.XDataType == reflect.Uint8:
+		mode = modeYCbCr
+	case iGray >= 0:
+		mode = modeGray
+	default:
+		return nil, false
+	}
+
+	// every band must be mapped, the extra bands (such as NIR) are kept
+	// by StdImage
+	used := 3
+	if mode == modeGray {
+		used = 1
+	}
+	if iAlpha >= 0 {
+		used++
+	}
+	if used != p.XChannels {
+		return nil, false
+	}
+
+	// the layouts of StdImage
+	if iAlpha < 0 {
+		if mode == modeGray && p.XChannels == 1 {
+			return nil, false
+		}
+		if mode == modeRGB && p.XChannels == 3 && iR == 0 && iG == 1 && iB == 2 {
+			return nil, false
+		}
+	}
+
+	is16 := p.XDataType == reflect.Uint16
+	size := SizeofKind(p.XDataType)
+	sample := func(row []byte, x, c int) uint16 {
+		i := (x*p.XChannels + c) * size
+		if is16 {
+			return PixSlice(row[i : i+2]).Uint16s()[0]
+		}
+		return uint16(row[i])
+	}
+
+	b := p.XRect
+	w, h := b.Dx(), b.Dy()
+
+	if mode == modeGray && iAlpha < 0 {
+		if is16 {
+			dst := image.NewGray16(b)
+			for y := 0; y < h; y++ {
+				row, off := p.XPix[y*p.XStride:], y*dst.Stride
+				for x := 0; x < w; x++ {
+					v := sample(row, x, iGray)
+					dst.Pix[off+0] = uint8(v >> 8)
+					dst.Pix[off+1] = uint8(v)
+					off += 2
+				}
+			}
+			return dst, true
+		}
+		dst := image.NewGray(b)
+		for y := 0; y < h; y++ {
+			row, off := p.XPix[y*p.XStride:], y*dst.Stride
+			for x := 0; x < w; x++ {
+				dst.Pix[off] = uint8(sample(row, x, iGray))
+				off++
+			}
+		}
+		return dst, true
+	}
+
+	var pix []byte
+	var stride int
+	if is16 {
+		dst := image.NewRGBA64(b)
+		m, pix, stride = dst, dst.Pix, dst.Stride
+	} else {
+		dst := image.NewRGBA(b)
+		m, pix, stride = dst, dst.Pix, dst.Stride
+	}
+
+	for y := 0; y < h; y++ {
+		row, off := p.XPix[y*p.XStride:], y*stride
+		for x := 0; x < w; x++ {
+			var v [4]uint16
+			switch mode {
+			case modeRGB:
+				v[0], v[1], v[2] = sample(row, x, iR), sample(row, x, iG), sample(row, x, iB)
+			case modeYCbCr:
+				r, g, b := color.YCbCrToRGB(uint8(sample(row, x, iY)), uint8(sample(row, x, iCb)), uint8(sample(row, x, iCr)))
+				v[0], v[1], v[2] = uint16(r), uint16(g), uint16(b)
+			default:
+				v[0] = sample(row, x, iGray)
+				v[1], v[2] = v[0], v[0]
+			}
+			switch {
+			case iAlpha >= 0 && is16:
+				v[3] = sample(row, x, iAlpha)
+				r, g, b, _ := color.NRGBA64{v[0], v[1], v[2], v[3]}.RGBA()
+				v[0], v[1], v[2] = uint16(r), uint16(g), uint16(b)
+			case iAlpha >= 0:
+				v[3] = sample(row, x, iAlpha)
+				r, g, b, _ := color.NRGBA{uint8(v[0]), uint8(v[1]), uint8(v[2]), uint8(v[3])}.RGBA()
+				v[0], v[1], v[2] = uint16(r>>8), uint16(g>>8), uint16(b>>8)
+			case is16:
+				v[3] = 0xFFFF
+			default:
+				v[3] = 0xFF
+			}
+
+			if is16 {
+				for i := 0; i < 4; i++ {
+					pix[off+2*i+0] = uint8(v[i] >> 8)
+					pix[off+2*i+1] = uint8(v[i])
+				}
+				off += 8
+			} else {
+				for i := 0; i < 4; i++ {
+					pix[off+i] = uint8(v[i])
+				}
+				off += 4
+			}
+		}
+	}
+	return m, true
+}
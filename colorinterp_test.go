@@ -0,0 +1,194 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMemPImage_StdImageWithColorInterp(t *testing.T) {
+	r := image.Rect(0, 0, 2, 1)
+
+	// BGR
+	bgr := NewMemPImage(r, 3, reflect.Uint8)
+	copy(bgr.XPix, []byte{3, 2, 1, 30, 20, 10})
+	m := bgr.StdImageWithColorInterp([]ColorInterp{GCI_BlueBand, GCI_GreenBand, GCI_RedBand})
+	if c := m.(*image.RGBA).RGBAAt(1, 0); c != (color.RGBA{10, 20, 30, 0xFF}) {
+		t.Fatalf("BGR: %v", c)
+	}
+
+	// alpha in the first band
+	argb := NewMemPImage(r, 4, reflect.Uint8)
+	copy(argb.XPix, []byte{0, 1, 2, 3, 128, 10, 20, 30})
+	m = argb.StdImageWithColorInterp([]ColorInterp{GCI_AlphaBand, GCI_RedBand, GCI_GreenBand, GCI_BlueBand})
+	if c := m.(*image.RGBA).RGBAAt(1, 0); c != color.RGBAModel.Convert(color.NRGBA{10, 20, 30, 128}) {
+		t.Fatalf("ARGB: %v", c)
+	}
+
+	// gray+alpha, 16-bit
+	ga := NewMemPImage(r, 2, reflect.Uint16)
+	ga.XPix.Uint16s()[2], ga.XPix.Uint16s()[3] = 1000, 0x8000
+	m = ga.StdImageWithColorInterp([]ColorInterp{GCI_GrayIndex, GCI_AlphaBand})
+	if c := m.(*image.RGBA64).RGBA64At(1, 0); c != color.RGBA64Model.Convert(color.NRGBA64{1000, 1000, 1000, 0x8000}) {
+		t.Fatalf("gray+alpha: %v", c)
+	}
+
+	// YCbCr
+	ycc := NewMemPImage(r, 3, reflect.Uint8)
+	copy(ycc.XPix, []byte{0, 0, 0, 100, 120, 140})
+	m = ycc.StdImageWithColorInterp([]ColorInterp{GCI_YCbCr_YBand, GCI_YCbCr_CbBand, GCI_YCbCr_CrBand})
+	cr, cg, cb := color.YCbCrToRGB(100, 120, 140)
+	if c := m.(*image.RGBA).RGBAAt(1, 0); c != (color.RGBA{cr, cg, cb, 0xFF}) {
+		t.Fatalf("YCbCr: %v", c)
+	}
+
+	// same as StdImage
+	rgb := NewMemPImage(r, 3, reflect.Uint8)
+	if m := rgb.StdImageWithColorInterp([]ColorInterp{GCI_RedBand, GCI_GreenBand, GCI_BlueBand}); m != image.Image(rgb) {
+		t.Fatalf("RGB: %T", m)
+	}
+	if _, ok := rgb.StdImageWithColorInterp([]ColorInterp{GCI_Undefined, GCI_Undefined, GCI_Undefined}).(*MemPImage); !ok {
+		t.Fatal("Undefined: expect *MemPImage")
+	}
+
+	// RGB+NIR, all the channels are kept
+	rgbn := NewMemPImage(r, 4, reflect.Uint8)
+	copy(rgbn.XPix, []byte{1, 2, 3, 4, 10, 20, 30, 40})
+	m = rgbn.StdImageWithColorInterp([]ColorInterp{GCI_RedBand, GCI_GreenBand, GCI_BlueBand, GCI_Undefined})
+	if m, ok := m.(*image.RGBA); !ok || !bytes.Equal(m.Pix, rgbn.XPix) {
+		t.Fatalf("RGB+Undefined: %v", m)
+	}
+}
+
+func TestDataset_SetColorInterpretations(t *testing.T) {
+	f, err := CreateDataset("", 2, 1, 3, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := f.SetColorInterpretations(GCI_RedBand); err == nil {
+		t.Fatal("expect error")
+	}
+	bgr := []ColorInterp{GCI_BlueBand, GCI_GreenBand, GCI_RedBand}
+	if err := f.SetColorInterpretations(bgr...); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.ColorInterpretations(); !reflect.DeepEqual(got, bgr) {
+		t.Fatalf("expect = %v, got = %v", bgr, got)
+	}
+
+	if err := f.WriteFromBuf(image.Rect(0, 0, 2, 1), []byte{3, 2, 1, 30, 20, 10}, 6); err != nil {
+		t.Fatal(err)
+	}
+	m, err := readImage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := m.(*image.RGBA).RGBAAt(1, 0); c != (color.RGBA{10, 20, 30, 0xFF}) {
+		t.Fatalf("BGR: %v", c)
+	}
+}
+
+func TestReadImage_extraBands(t *testing.T) {
+	f, err := CreateDataset("", 2, 1, 4, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := f.SetColorInterpretations(GCI_RedBand, GCI_GreenBand, GCI_BlueBand, GCI_Undefined); err != nil {
+		t.Fatal(err)
+	}
+	pix := []byte{1, 2, 3, 4, 10, 20, 30, 40}
+	if err := f.WriteFromBuf(image.Rect(0, 0, 2, 1), pix, 8); err != nil {
+		t.Fatal(err)
+	}
+	m, err := readImage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := m.(*image.RGBA); !ok || !bytes.Equal(m.Pix, pix) {
+		t.Fatalf("expect all the bands kept, got %v", m)
+	}
+}
+
+func TestSave_NRGBA(t *testing.T) {
+	m0 := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+	for i := range m0.Pix {
+		m0.Pix[i] = uint8(i * 7)
+	}
+
+	const filename = "z_test_nrgba.tiff"
+	defer os.Remove(filename)
+
+	if err := Save(filename, m0, nil); err != nil {
+		t.Fatal(err)
+	}
+	m1, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba, ok := m1.(*image.RGBA)
+	if !ok {
+		t.Fatalf("expect *image.RGBA, got %T", m1)
+	}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			if c, expect := rgba.At(x, y), color.RGBAModel.Convert(m0.At(x, y)); c != expect {
+				t.Fatalf("(%d, %d): expect = %v, got = %v", x, y, expect, c)
+			}
+		}
+	}
+}
+
+func TestSave_RGBA(t *testing.T) {
+	m0 := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for i, a := range []uint8{0, 1, 64, 128, 200, 254, 255, 128, 100, 37, 10, 255} {
+		c := uint8(i * 21)
+		if c > a {
+			c = a
+		}
+		m0.Pix[4*i+0], m0.Pix[4*i+1], m0.Pix[4*i+2], m0.Pix[4*i+3] = c, c/2, c/3, a
+	}
+
+	const filename = "z_test_rgba.tiff"
+	defer os.Remove(filename)
+
+	if err := Save(filename, m0, nil); err != nil {
+		t.Fatal(err)
+	}
+	m1, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba, ok := m1.(*image.RGBA)
+	if !ok {
+		t.Fatalf("expect *image.RGBA, got %T", m1)
+	}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			expect := color.RGBAModel.Convert(color.NRGBAModel.Convert(m0.At(x, y)))
+			if c := rgba.At(x, y); c != expect {
+				t.Fatalf("(%d, %d): expect = %v, got = %v", x, y, expect, c)
+			}
+			if c0, c1 := m0.RGBAAt(x, y), rgba.RGBAAt(x, y); absDiff(c0.R, c1.R) > 1 || c0.A != c1.A {
+				t.Fatalf("(%d, %d): %v != %v", x, y, c0, c1)
+			}
+		}
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
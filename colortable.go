@@ -0,0 +1,265 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+import "C"
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+)
+
+type PaletteInterp int
+
+const (
+	GPI_Gray PaletteInterp = iota // "Gray", C1 is the gray value
+	GPI_RGB                       // "RGB", C1..C4 are red, green, blue and alpha
+	GPI_CMYK                      // "CMYK", C1..C4 are cyan, magenta, yellow and black
+	GPI_HLS                       // "HLS", C1..C3 are hue, lightness and saturation
+)
+
+func NewPaletteInterp(name string) PaletteInterp {
+	switch strings.ToUpper(name) {
+	case "GRAY":
+		return GPI_Gray
+	case "RGB":
+		return GPI_RGB
+	case "CMYK":
+		return GPI_CMYK
+	case "HLS":
+		return GPI_HLS
+	}
+	return GPI_RGB
+}
+
+func (p PaletteInterp) Name() string {
+	return C.GoString(C.GDALGetPaletteInterpretationName(C.GDALPaletteInterp(p)))
+}
+
+// ColorEntry is an entry of ColorTable, the values are in [0, 255].
+type ColorEntry struct {
+	C1, C2, C3, C4 int16
+}
+
+// ColorTable is the color table (palette) of a band.
+type ColorTable struct {
+	Interp  PaletteInterp
+	Entries []ColorEntry
+}
+
+// NewColorTableFromPalette returns the GPI_RGB color table of pal.
+func NewColorTableFromPalette(pal color.Palette) *ColorTable {
+	ct := &ColorTable{
+		Interp:  GPI_RGB,
+		Entries: make([]ColorEntry, len(pal)),
+	}
+	for i, c := range pal {
+		v := color.NRGBAModel.Convert(c).(color.NRGBA)
+		ct.Entries[i] = ColorEntry{int16(v.R), int16(v.G), int16(v.B), int16(v.A)}
+	}
+	return ct
+}
+
+// Palette returns the colors of the entries.
+func (p *ColorTable) Palette() color.Palette {
+	pal := make(color.Palette, len(p.Entries))
+	for i, v := range p.Entries {
+		pal[i] = p.entryColor(v)
+	}
+	return pal
+}
+
+func (p *ColorTable) entryColor(v ColorEntry) color.Color {
+	switch p.Interp {
+	case GPI_Gray:
+		return color.Gray{Y: clampUint8(v.C1)}
+	case GPI_CMYK:
+		return color.CMYK{C: clampUint8(v.C1), M: clampUint8(v.C2), Y: clampUint8(v.C3), K: clampUint8(v.C4)}
+	case GPI_HLS:
+		r, g, b := hlsToRGB(float64(v.C1)/255, float64(v.C2)/255, float64(v.C3)/255)
+		return color.NRGBA{R: r, G: g, B: b, A: 0xFF}
+	}
+	return color.NRGBA{R: clampUint8(v.C1), G: clampUint8(v.C2), B: clampUint8(v.C3), A: clampUint8(v.C4)}
+}
+
+func clampUint8(v int16) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 0xFF {
+		return 0xFF
+	}
+	return uint8(v)
+}
+
+func hlsToRGB(h, l, s float64) (r, g, b uint8) {
+	if s == 0 {
+		v := uint8(l*255 + 0.5)
+		return v, v, v
+	}
+	var m2 float64
+	if l <= 0.5 {
+		m2 = l * (1 + s)
+	} else {
+		m2 = l + s - l*s
+	}
+	m1 := 2*l - m2
+	hue := func(h float64) uint8 {
+		if h < 0 {
+			h += 1
+		}
+		if h > 1 {
+			h -= 1
+		}
+		var v float64
+		switch {
+		case h < 1.0/6:
+			v = m1 + (m2-m1)*h*6
+		case h < 1.0/2:
+			v = m2
+		case h < 2.0/3:
+			v = m1 + (m2-m1)*(2.0/3-h)*6
+		default:
+			v = m1
+		}
+		return uint8(v*255 + 0.5)
+	}
+	return hue(h + 1.0/3), hue(h), hue(h - 1.0/3)
+}
+
+// ColorTable returns the color table of the band, ok is false if the band
+// has no color table.
+func (p *RasterBand) ColorTable() (ct *ColorTable, ok bool) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return getColorTable(p.poBand)
+}
+
+// SetColorTable sets the color table of the band, nil removes the color
+// table (not supported by all the drivers).
+func (p *RasterBand) SetColorTable(ct *ColorTable) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	if err := setColorTable(p.poBand, ct); err != nil {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).SetColorTable failed: %w", p.ds.Filename, p.index, err)
+	}
+	return nil
+}
+
+func getColorTable(poBand C.GDALRasterBandH) (ct *ColorTable, ok bool) {
+	hTable := C.GDALGetRasterColorTable(poBand)
+	if hTable == nil {
+		return nil, false
+	}
+
+	n := int(C.GDALGetColorEntryCount(hTable))
+	ct = &ColorTable{
+		Interp:  PaletteInterp(C.GDALGetPaletteInterpretation(hTable)),
+		Entries: make([]ColorEntry, n),
+	}
+	for i := 0; i < n; i++ {
+		if v := C.GDALGetColorEntry(hTable, C.int(i)); v != nil {
+			ct.Entries[i] = ColorEntry{int16(v.c1), int16(v.c2), int16(v.c3), int16(v.c4)}
+		}
+	}
+	return ct, true
+}
+
+func setColorTable(poBand C.GDALRasterBandH, ct *ColorTable) error {
+	var hTable C.GDALColorTableH
+	if ct != nil {
+		hTable = C.GDALCreateColorTable(C.GDALPaletteInterp(ct.Interp))
+		defer C.GDALDestroyColorTable(hTable)
+
+		for i, v := range ct.Entries {
+			entry := C.GDALColorEntry{
+				c1: C.short(v.C1),
+				c2: C.short(v.C2),
+				c3: C.short(v.C3),
+				c4: C.short(v.C4),
+			}
+			C.GDALSetColorEntry(hTable, C.int(i), &entry)
+		}
+	}
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetRasterColorTable(poBand, hTable)
+	})
+	if cErr != C.CE_None {
+		if cplErr != nil {
+			return cplErr
+		}
+		return fmt.Errorf("gdal: GDALSetRasterColorTable failed.")
+	}
+	return nil
+}
+
+// palette returns the palette of the 8-bit paletted dataset, nil if the
+// dataset is not paletted. The palette has at most 256 colors.
+func (p *Dataset) palette() color.Palette {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p._Channels != 1 || p._DataType != reflect.Uint8 {
+		return nil
+	}
+	poBand := C.GDALGetRasterBand(p.poDataset, 1)
+	if ColorInterp(C.GDALGetRasterColorInterpretation(poBand)) != GCI_PaletteIndex {
+		return nil
+	}
+	ct, ok := getColorTable(poBand)
+	if !ok || len(ct.Entries) == 0 {
+		return nil
+	}
+	if len(ct.Entries) > 256 {
+		ct.Entries = ct.Entries[:256]
+	}
+	return ct.Palette()
+}
+
+// padPalette pads the palette with opaque black to n colors, the pixel
+// values out of the color table are legal in GDAL but make image.Paletted
+// panic.
+func padPalette(pal color.Palette, n int) color.Palette {
+	if len(pal) >= n {
+		return pal
+	}
+	padded := make(color.Palette, n)
+	copy(padded, pal)
+	for i := len(pal); i < n; i++ {
+		padded[i] = color.RGBA{A: 0xff}
+	}
+	return padded
+}
+
+// setPalette sets the color table of the first band.
+func (p *Dataset) setPalette(pal color.Palette) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := setColorTable(C.GDALGetRasterBand(p.poDataset, 1), NewColorTableFromPalette(pal)); err != nil {
+		return fmt.Errorf("gdal: Dataset(%q).setPalette failed: %w", p.Filename, err)
+	}
+	return nil
+}
+
+// memPImageFromPaletted returns the 1 channel uint8 image of the palette
+// indexes of m, the pixels are shared.
+func memPImageFromPaletted(m *image.Paletted) *MemPImage {
+	return &MemPImage{
+		XMemPMagic: MemPMagic,
+		XRect:      m.Rect,
+		XChannels:  1,
+		XDataType:  reflect.Uint8,
+		XPix:       m.Pix,
+		XStride:    m.Stride,
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLoad_paletted(t *testing.T) {
+	const filename = "./testdata/video-001-paletted.tiff"
+
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.ColorModel.(color.Palette); !ok {
+		t.Fatalf("ColorModel: %T", cfg.ColorModel)
+	}
+
+	m, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, ok := m.(*image.Paletted)
+	if !ok {
+		t.Fatalf("expect *image.Paletted, got %T", m)
+	}
+	if len(pm.Palette) == 0 || len(pm.Palette) > 256 {
+		t.Fatalf("palette size: %d", len(pm.Palette))
+	}
+
+	const tmpname = "z_test_paletted.tiff"
+	defer os.Remove(tmpname)
+
+	if err := Save(tmpname, pm, nil); err != nil {
+		t.Fatal(err)
+	}
+	m2, err := Load(tmpname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm2, ok := m2.(*image.Paletted)
+	if !ok {
+		t.Fatalf("expect *image.Paletted, got %T", m2)
+	}
+	if !bytes.Equal(pm.Pix, pm2.Pix) {
+		t.Fatal("pixels not equal")
+	}
+	for i := range pm.Palette {
+		r0, g0, b0, a0 := pm.Palette[i].RGBA()
+		r1, g1, b1, a1 := pm2.Palette[i].RGBA()
+		if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+			t.Fatalf("palette[%d]: %v != %v", i, pm.Palette[i], pm2.Palette[i])
+		}
+	}
+}
+
+func TestRasterBand_ColorTable(t *testing.T) {
+	f, err := CreateDataset("", 4, 4, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	band, err := f.Band(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := band.ColorTable(); ok {
+		t.Fatal("expect no color table")
+	}
+
+	ct := &ColorTable{
+		Interp: GPI_RGB,
+		Entries: []ColorEntry{
+			{0, 0, 0, 0},
+			{255, 0, 0, 255},
+			{0, 128, 255, 255},
+		},
+	}
+	if err := band.SetColorTable(ct); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := band.ColorTable()
+	if !ok {
+		t.Fatal("expect color table")
+	}
+	if !reflect.DeepEqual(got, ct) {
+		t.Fatalf("expect = %v, got = %v", ct, got)
+	}
+	if ci := band.ColorInterpretation(); ci != GCI_PaletteIndex {
+		t.Fatalf("ColorInterpretation: %v", ci.Name())
+	}
+
+	pal := got.Palette()
+	if c := pal[2].(color.NRGBA); c != (color.NRGBA{0, 128, 255, 255}) {
+		t.Fatalf("palette[2]: %v", c)
+	}
+}
+
+func TestReadImage_outOfPalette(t *testing.T) {
+	f, err := CreateDataset("", 4, 1, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := f.setPalette(color.Palette{color.Black, color.White}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.WriteFromBuf(image.Rect(0, 0, 4, 1), []byte{0, 1, 7, 255}, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := readImage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, ok := m.(*image.Paletted)
+	if !ok {
+		t.Fatalf("expect *image.Paletted, got %T", m)
+	}
+	if len(pm.Palette) != 256 {
+		t.Fatalf("len(palette): %d", len(pm.Palette))
+	}
+	if r, g, b, a := pm.At(1, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff || a != 0xffff {
+		t.Fatalf("At(1, 0): %v", pm.At(1, 0))
+	}
+	if r, g, b, a := pm.At(3, 0).RGBA(); r != 0 || g != 0 || b != 0 || a != 0xffff {
+		t.Fatalf("At(3, 0): %v", pm.At(3, 0))
+	}
+}
+
+func TestColorTable_Palette(t *testing.T) {
+	for _, v := range []struct {
+		ct     ColorTable
+		expect color.Color
+	}{
+		{ColorTable{GPI_Gray, []ColorEntry{{100, 0, 0, 0}}}, color.Gray{100}},
+		{ColorTable{GPI_CMYK, []ColorEntry{{0, 255, 255, 0}}}, color.NRGBA{255, 0, 0, 255}},
+		{ColorTable{GPI_HLS, []ColorEntry{{0, 127, 255, 0}}}, color.NRGBA{254, 0, 0, 255}},
+	} {
+		r0, g0, b0, a0 := v.expect.RGBA()
+		r1, g1, b1, a1 := v.ct.Palette()[0].RGBA()
+		if r0>>8 != r1>>8 || g0>>8 != g1>>8 || b0>>8 != b1>>8 || a0>>8 != a1>>8 {
+			t.Fatalf("%s: expect = %v, got = %v", v.ct.Interp.Name(), v.expect, v.ct.Palette()[0])
+		}
+	}
+}
@@ -4,22 +4,59 @@
 
 package gdal
 
-//#include <gdal.h>
+/*
+#include <gdal.h>
+
+// GDT_Int64/GDT_UInt64 are added in GDAL 3.5, GDT_Int8 in GDAL 3.7.
+static GDALDataType gdtInt8() {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(3,7,0)
+	return GDT_Int8;
+#else
+	return GDT_Unknown;
+#endif
+}
+static GDALDataType gdtInt64() {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(3,5,0)
+	return GDT_Int64;
+#else
+	return GDT_Unknown;
+#endif
+}
+static GDALDataType gdtUInt64() {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(3,5,0)
+	return GDT_UInt64;
+#else
+	return GDT_Unknown;
+#endif
+}
+*/
 import "C"
 import (
 	"reflect"
 )
 
+// The data types depending on the GDAL version, GDT_Unknown if unsupported.
+var (
+	gdt_Int8   = C.gdtInt8()
+	gdt_Int64  = C.gdtInt64()
+	gdt_UInt64 = C.gdtUInt64()
+)
+
+// gdalDataType returns the GDAL data type of the Go kind, GDT_Unknown if
+// unsupported.
+//
+// The complex64 and complex128 are GDT_CFloat32 and GDT_CFloat64, the
+// Int8, Int64 and Uint64 need GDAL 3.5 (Int64/Uint64) or 3.7 (Int8).
 func gdalDataType(dataType reflect.Kind) C.GDALDataType {
 	switch dataType {
 	case reflect.Int8:
-		// invalid
+		return gdt_Int8
 	case reflect.Int16:
 		return C.GDT_Int16
 	case reflect.Int32:
 		return C.GDT_Int32
 	case reflect.Int64:
-		// invalid
+		return gdt_Int64
 	case reflect.Uint8:
 		return C.GDT_Byte
 	case reflect.Uint16:
@@ -27,23 +64,28 @@ func gdalDataType(dataType reflect.Kind) C.GDALDataType {
 	case reflect.Uint32:
 		return C.GDT_UInt32
 	case reflect.Uint64:
-		// invalid
+		return gdt_UInt64
 	case reflect.Float32:
 		return C.GDT_Float32
 	case reflect.Float64:
 		return C.GDT_Float64
 	case reflect.Complex64:
-		// invalid
+		return C.GDT_CFloat32
 	case reflect.Complex128:
-		// invalid
+		return C.GDT_CFloat64
 	}
 	return C.GDT_Unknown
 }
 
+// goDataType returns the Go kind of the GDAL data type, reflect.Invalid if
+// unsupported.
+//
+// Go has no complex integer types, the GDT_CInt16 is read as complex64 and
+// the GDT_CInt32 is read as complex128 (without losing precision).
 func goDataType(dataType C.GDALDataType) reflect.Kind {
 	switch dataType {
 	case C.GDT_Unknown:
-		// invalid
+		return reflect.Invalid
 	case C.GDT_Byte:
 		return reflect.Uint8
 	case C.GDT_UInt16:
@@ -59,15 +101,19 @@ func goDataType(dataType C.GDALDataType) reflect.Kind {
 	case C.GDT_Float64:
 		return reflect.Float64
 	case C.GDT_CInt16:
-		// invalid
+		return reflect.Complex64
 	case C.GDT_CInt32:
-		// invalid
+		return reflect.Complex128
 	case C.GDT_CFloat32:
-		// invalid
+		return reflect.Complex64
 	case C.GDT_CFloat64:
-		// invalid
-	case C.GDT_TypeCount:
-		// invalid
+		return reflect.Complex128
+	case gdt_Int8:
+		return reflect.Int8
+	case gdt_Int64:
+		return reflect.Int64
+	case gdt_UInt64:
+		return reflect.Uint64
 	}
 	return reflect.Invalid
 }
@@ -0,0 +1,99 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestDataType(t *testing.T) {
+	for _, kind := range []reflect.Kind{
+		reflect.Int16, reflect.Int32,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Float32, reflect.Float64,
+		reflect.Complex64, reflect.Complex128,
+	} {
+		if got := goDataType(gdalDataType(kind)); got != kind {
+			t.Fatalf("%v: got = %v", kind, got)
+		}
+	}
+	for _, kind := range []reflect.Kind{reflect.Int8, reflect.Int64, reflect.Uint64} {
+		if gdalDataType(kind) == 0 {
+			t.Logf("%v: unsupported by GDAL %d.%d", kind, MajorVersion, MinorVersion)
+			continue
+		}
+		if got := goDataType(gdalDataType(kind)); got != kind {
+			t.Fatalf("%v: got = %v", kind, got)
+		}
+	}
+	if got := goDataType(0); got != reflect.Invalid {
+		t.Fatalf("GDT_Unknown: got = %v", got)
+	}
+}
+
+func TestPixSlice_Complex(t *testing.T) {
+	pix := make(PixSlice, 32)
+	if n := len(pix.Complex64s()); n != 4 {
+		t.Fatalf("Complex64s: len = %d", n)
+	}
+	if n := len(pix.Complex128s()); n != 2 {
+		t.Fatalf("Complex128s: len = %d", n)
+	}
+
+	pix.Complex128s()[1] = complex(1.5, -2)
+	if v := pix.Value(1, reflect.Complex128); v != 1.5 {
+		t.Fatalf("Value: %v", v)
+	}
+}
+
+func TestDataset_complex(t *testing.T) {
+	for _, kind := range []reflect.Kind{
+		reflect.Complex64, reflect.Complex128,
+		reflect.Int8, reflect.Int64, reflect.Uint64,
+	} {
+		if gdalDataType(kind) == 0 {
+			continue
+		}
+
+		r := image.Rect(0, 0, 4, 3)
+		m := NewMemPImage(r, 2, kind)
+		for i := 0; i < len(m.XPix)/SizeofKind(kind); i++ {
+			if kind == reflect.Complex64 || kind == reflect.Complex128 {
+				if kind == reflect.Complex64 {
+					m.XPix.Complex64s()[i] = complex(float32(i), float32(-i))
+				} else {
+					m.XPix.Complex128s()[i] = complex(float64(i), float64(-i))
+				}
+			} else {
+				m.XPix.SetValue(i, kind, float64(i-5))
+			}
+		}
+
+		f, err := CreateDataset("", r.Dx(), r.Dy(), 2, kind, &Options{DriverName: "MEM"})
+		if err != nil {
+			t.Fatalf("%v: %v", kind, err)
+		}
+		if err := f.WriteFromBuf(r, m.XPix, m.XStride); err != nil {
+			f.Close()
+			t.Fatalf("%v: %v", kind, err)
+		}
+		got, err := f.Read(r)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%v: %v", kind, err)
+		}
+
+		p := got.(*MemPImage)
+		if p.XDataType != kind {
+			t.Fatalf("%v: DataType = %v", kind, p.XDataType)
+		}
+		if string(p.XPix) != string(m.XPix) {
+			t.Fatalf("%v: pixels not equal", kind)
+		}
+		_ = p.At(1, 1) // MemPColor of the kind
+	}
+}
@@ -4,9 +4,48 @@
 
 package gdal
 
-//#include <gdal.h>
-//#include <stdint.h>
-//#include <stdlib.h>
+/*
+#include <gdal.h>
+#include <gdal_version.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+// rasterIO is GDALRasterIO with the progress in [dfMin, dfMax] (GDAL >= 2.0).
+static CPLErr rasterIO(
+	GDALRasterBandH hBand, GDALRWFlag eRWFlag,
+	int nXOff, int nYOff, int nXSize, int nYSize,
+	void *pData, int nBufXSize, int nBufYSize, GDALDataType eBufType,
+	int nPixelSpace, int nLineSpace,
+	double dfMin, double dfMax, GDALProgressFunc pfnProgress, void *pProgressData
+) {
+#if GDAL_VERSION_MAJOR >= 2
+	if(pfnProgress != NULL) {
+		CPLErr eErr;
+		GDALRasterIOExtraArg sExtraArg;
+		INIT_RASTERIO_EXTRA_ARG(sExtraArg);
+		sExtraArg.pfnProgress = GDALScaledProgress;
+		sExtraArg.pProgressData = GDALCreateScaledProgress(dfMin, dfMax, pfnProgress, pProgressData);
+		eErr = GDALRasterIOEx(hBand, eRWFlag,
+			nXOff, nYOff, nXSize, nYSize,
+			pData, nBufXSize, nBufYSize, eBufType,
+			(GSpacing)nPixelSpace, (GSpacing)nLineSpace, &sExtraArg
+		);
+		GDALDestroyScaledProgress(sExtraArg.pProgressData);
+		return eErr;
+	}
+#else
+	if(pfnProgress != NULL && !pfnProgress(dfMin, NULL, pProgressData)) {
+		CPLError(CE_Failure, CPLE_UserInterrupt, "User terminated");
+		return CE_Failure;
+	}
+#endif
+	return GDALRasterIO(hBand, eRWFlag,
+		nXOff, nYOff, nXSize, nYSize,
+		pData, nBufXSize, nBufYSize, eBufType,
+		nPixelSpace, nLineSpace
+	);
+}
+*/
 import "C"
 import (
 	"fmt"
@@ -36,13 +75,23 @@ const (
 	ResampleType_Average                            // "AVERAGE"
 	ResampleType_Mode                               // "MODE"
 	ResampleType_AverageMagpase                     // "AVERAGE_MAGPHASE"
+	ResampleType_Bilinear                           // "BILINEAR"
+	ResampleType_CubicSpline                        // "CUBICSPLINE"
+	ResampleType_Lanczos                            // "LANCZOS"
+	ResampleType_Min                                // "MIN", warp only
+	ResampleType_Max                                // "MAX", warp only
+	ResampleType_Med                                // "MED", warp only
+	ResampleType_Q1                                 // "Q1", warp only
+	ResampleType_Q3                                 // "Q3", warp only
+	ResampleType_Sum                                // "SUM", warp only (GDAL >= 3.1)
+	ResampleType_RMS                                // "RMS" (GDAL >= 3.3)
 )
 
 func NewResampleType(name string) ResampleType {
 	switch strings.ToUpper(name) {
 	case "NONE":
 		return ResampleType_Nil
-	case "NEAREST":
+	case "NEAREST", "NEAR":
 		return ResampleType_Nearest
 	case "GAUSS":
 		return ResampleType_Gauss
@@ -54,6 +103,26 @@ func NewResampleType(name string) ResampleType {
 		return ResampleType_Mode
 	case "AVERAGE_MAGPHASE":
 		return ResampleType_AverageMagpase
+	case "BILINEAR":
+		return ResampleType_Bilinear
+	case "CUBICSPLINE":
+		return ResampleType_CubicSpline
+	case "LANCZOS":
+		return ResampleType_Lanczos
+	case "MIN":
+		return ResampleType_Min
+	case "MAX":
+		return ResampleType_Max
+	case "MED":
+		return ResampleType_Med
+	case "Q1":
+		return ResampleType_Q1
+	case "Q3":
+		return ResampleType_Q3
+	case "SUM":
+		return ResampleType_Sum
+	case "RMS":
+		return ResampleType_RMS
 	}
 	return ResampleType_Nil
 }
@@ -74,10 +143,51 @@ func (p ResampleType) Name() string {
 		return "MODE"
 	case ResampleType_AverageMagpase:
 		return "AVERAGE_MAGPHASE"
+	case ResampleType_Bilinear:
+		return "BILINEAR"
+	case ResampleType_CubicSpline:
+		return "CUBICSPLINE"
+	case ResampleType_Lanczos:
+		return "LANCZOS"
+	case ResampleType_Min:
+		return "MIN"
+	case ResampleType_Max:
+		return "MAX"
+	case ResampleType_Med:
+		return "MED"
+	case ResampleType_Q1:
+		return "Q1"
+	case ResampleType_Q3:
+		return "Q3"
+	case ResampleType_Sum:
+		return "SUM"
+	case ResampleType_RMS:
+		return "RMS"
 	}
 	return "NONE"
 }
 
+// isOverviewResampling reports whether p is supported by GDALBuildOverviews.
+func (p ResampleType) isOverviewResampling() bool {
+	switch p {
+	case ResampleType_Min, ResampleType_Max, ResampleType_Med,
+		ResampleType_Q1, ResampleType_Q3, ResampleType_Sum:
+		return false
+	}
+	return true
+}
+
+// isTranslateResampling reports whether p is supported by gdal_translate -r.
+func (p ResampleType) isTranslateResampling() bool {
+	switch p {
+	case ResampleType_Nil, ResampleType_Nearest, ResampleType_Bilinear,
+		ResampleType_Cubic, ResampleType_CubicSpline, ResampleType_Lanczos,
+		ResampleType_Average, ResampleType_Mode, ResampleType_RMS:
+		return true
+	}
+	return false
+}
+
 // GDAL Raster Formats
 //
 // See http://www.gdal.org/formats_list.html
@@ -89,10 +199,13 @@ func (p ResampleType) Name() string {
 //	Transform[4] /* 0 */
 //	Transform[5] /* n-s pixel resolution (negative value) */
 //
+// NoData is the NoData value of the bands, nil means not set.
+//
 type Options struct {
 	DriverName string
 	Projection string
 	Transform  [6]float64
+	NoData     *float64
 	ExtOptions map[string]string
 }
 
@@ -107,7 +220,9 @@ type Dataset struct {
 
 	mu           sync.Mutex
 	poDataset    C.GDALDatasetH
+	access       Access
 	resampleType ResampleType
+	vsiFilename  string // the /vsimem file removed by Close
 
 	buildOverviewsRunning uint32 // atomic.LoadUint32
 }
@@ -116,28 +231,44 @@ func OpenDataset(filename string, flag Access) (p *Dataset, err error) {
 	cname := C.CString(filename)
 	defer C.free(unsafe.Pointer(cname))
 
-	p = new(Dataset)
-	p.Opt = new(Options)
-
+	var eAccess C.GDALAccess
 	switch flag {
 	case GA_ReadOnly:
-		p.poDataset = C.GDALOpen(cname, C.GA_ReadOnly)
+		eAccess = C.GA_ReadOnly
 	case GA_Update:
-		p.poDataset = C.GDALOpen(cname, C.GA_Update)
+		eAccess = C.GA_Update
 	default:
 		err = fmt.Errorf("gdal: OpenImage(%q), unknown flag(%d).", filename, int(flag))
 		return
 	}
-	if p.poDataset == nil {
-		err = fmt.Errorf("gdal: OpenImage(%q) failed.", filename)
+	var poDataset C.GDALDatasetH
+	cplErr := cplCapture(func() {
+		poDataset = C.GDALOpen(cname, eAccess)
+	})
+	if poDataset == nil {
+		err = cplErrorf(cplErr, "gdal: OpenImage(%q) failed", filename)
 		return
 	}
 
-	p.Filename = filename
+	p = newDatasetFromHandle(filename, poDataset, flag)
+	return
+}
+
+// newDatasetFromHandle wraps an opened GDAL dataset handle.
+func newDatasetFromHandle(filename string, poDataset C.GDALDatasetH, flag Access) (p *Dataset) {
+	p = &Dataset{
+		Filename:  filename,
+		Opt:       new(Options),
+		poDataset: poDataset,
+		access:    flag,
+	}
 	p._Width = int(C.GDALGetRasterXSize(p.poDataset))
 	p._Height = int(C.GDALGetRasterYSize(p.poDataset))
 	p._Channels = int(C.GDALGetRasterCount(p.poDataset))
-	p._DataType = goDataType(C.GDALGetRasterDataType(C.GDALGetRasterBand(p.poDataset, 1)))
+	if p._Channels > 0 {
+		// container formats (such as NetCDF/HDF5) may have no bands, see Subdatasets
+		p._DataType = goDataType(C.GDALGetRasterDataType(C.GDALGetRasterBand(p.poDataset, 1)))
+	}
 
 	p.Opt.DriverName = C.GoString(C.GDALGetDriverShortName(C.GDALGetDatasetDriver(p.poDataset)))
 	p.Opt.Projection = C.GoString(C.GDALGetProjectionRef(p.poDataset))
@@ -149,6 +280,11 @@ func OpenDataset(filename string, flag Access) (p *Dataset, err error) {
 			p.Opt.Transform[i] = float64(padfTransform[i])
 		}
 	}
+	if p._Channels > 0 {
+		if nodata, ok := getNoDataValue(C.GDALGetRasterBand(p.poDataset, 1)); ok {
+			p.Opt.NoData = &nodata
+		}
+	}
 
 	return
 }
@@ -159,12 +295,19 @@ func OpenDatasetWithOverviews(filename string, resampleType ResampleType, flag A
 		return nil, err
 	}
 
-	p.SetResampleType(resampleType)
+	if err = p.SetResampleType(resampleType); err != nil {
+		p.Close()
+		return nil, err
+	}
 	p.BuildOverviewsIfNotExists()
 	return p, nil
 }
 
 func CreateDataset(filename string, width, height, channels int, dataType reflect.Kind, opt *Options) (p *Dataset, err error) {
+	if gdalDataType(dataType) == C.GDT_Unknown {
+		return nil, fmt.Errorf("gdal: CreateImage(%q), unsupported data type: %v", filename, dataType)
+	}
+
 	cname := C.CString(filename)
 	defer C.free(unsafe.Pointer(cname))
 
@@ -175,6 +318,7 @@ func CreateDataset(filename string, width, height, channels int, dataType reflec
 		_Channels: channels,
 		_DataType: dataType,
 		Opt:       new(Options),
+		access:    GA_Update,
 	}
 
 	if opt != nil {
@@ -209,15 +353,17 @@ func CreateDataset(filename string, width, height, channels int, dataType reflec
 
 	poDriver := C.GDALGetDriverByName(cDriverName)
 	if poDriver == nil {
-		err = fmt.Errorf("gdal: CreateImage(%q) failed.", filename)
+		err = fmt.Errorf("gdal: CreateImage(%q), unknown driver %q.", filename, p.Opt.DriverName)
 		return
 	}
-	p.poDataset = C.GDALCreate(poDriver, cname,
-		C.int(width), C.int(height), C.int(channels),
-		gdalDataType(p._DataType), (**C.char)(unsafe.Pointer(&opts[0])),
-	)
+	cplErr := cplCapture(func() {
+		p.poDataset = C.GDALCreate(poDriver, cname,
+			C.int(width), C.int(height), C.int(channels),
+			gdalDataType(p._DataType), (**C.char)(unsafe.Pointer(&opts[0])),
+		)
+	})
 	if p.poDataset == nil {
-		err = fmt.Errorf("gdal: CreateImage(%q) failed.", filename)
+		err = cplErrorf(cplErr, "gdal: CreateImage(%q) failed", filename)
 		return
 	}
 
@@ -225,17 +371,36 @@ func CreateDataset(filename string, width, height, channels int, dataType reflec
 	for i := 0; i < len(padfTransform); i++ {
 		padfTransform[i] = C.double(p.Opt.Transform[i])
 	}
-	if C.GDALSetProjection(p.poDataset, cProjName) != C.CE_None {
-		log.Printf("gdal: GDALSetProjection(%q, %s) failed!\n", filename, cProjName)
+	var cErr C.CPLErr
+	cplErr = cplCapture(func() {
+		cErr = C.GDALSetProjection(p.poDataset, cProjName)
+	})
+	if cErr != C.CE_None {
+		log.Println(cplErrorf(cplErr, "gdal: GDALSetProjection(%q, %q) failed", filename, p.Opt.Projection))
+	}
+	cplErr = cplCapture(func() {
+		cErr = C.GDALSetGeoTransform(p.poDataset, &padfTransform[0])
+	})
+	if cErr != C.CE_None {
+		log.Println(cplErrorf(cplErr, "gdal: GDALSetGeoTransform(%q, %v) failed", filename, p.Opt.Transform))
 	}
-	if C.GDALSetGeoTransform(p.poDataset, &padfTransform[0]) != C.CE_None {
-		log.Printf("gdal: GDALSetGeoTransform(%q, %v) failed!\n", filename, padfTransform)
+	if p.Opt.NoData != nil {
+		for nBandId := 0; nBandId < p._Channels; nBandId++ {
+			pBand := C.GDALGetRasterBand(p.poDataset, C.int(nBandId+1))
+			if err := setNoDataValue(pBand, *p.Opt.NoData); err != nil {
+				log.Printf("gdal: CreateImage(%q), SetNoDataValue(%v) failed: %v\n", filename, *p.Opt.NoData, err)
+			}
+		}
 	}
 
 	return
 }
 
 func CreateDatasetCopy(filename string, src *Dataset, opt *Options) (p *Dataset, err error) {
+	return createDatasetCopy(filename, src, opt, nil)
+}
+
+func createDatasetCopy(filename string, src *Dataset, opt *Options, h *progressHandle) (p *Dataset, err error) {
 	src.mu.Lock()
 	defer src.mu.Unlock()
 
@@ -249,6 +414,7 @@ func CreateDatasetCopy(filename string, src *Dataset, opt *Options) (p *Dataset,
 		_Channels: src._Channels,
 		_DataType: src._DataType,
 		Opt:       new(Options),
+		access:    GA_Update,
 	}
 
 	if opt != nil {
@@ -273,7 +439,7 @@ func CreateDatasetCopy(filename string, src *Dataset, opt *Options) (p *Dataset,
 	optsList := make([]string, 0, len(p.Opt.ExtOptions))
 
 	for k, v := range p.Opt.ExtOptions {
-		optsList = append(optsList, k+":"+v)
+		optsList = append(optsList, k+"="+v)
 	}
 	for i := 0; i < len(optsList); i++ {
 		opts[i] = C.CString(optsList[i])
@@ -285,19 +451,27 @@ func CreateDatasetCopy(filename string, src *Dataset, opt *Options) (p *Dataset,
 
 	poDriver := C.GDALGetDriverByName(cDriverName)
 	if poDriver == nil {
-		err = fmt.Errorf("gdal: CreateImage(%q) failed.", filename)
+		err = fmt.Errorf("gdal: CreateDatasetCopy(%q), unknown driver %q.", filename, p.Opt.DriverName)
 		return
 	}
 
-	p.poDataset = C.GDALCreateCopy(
-		poDriver, cname, src.poDataset, C.FALSE,
-		(**C.char)(unsafe.Pointer(&opts[0])),
-		nil, nil,
-	)
+	cplErr := cplCapture(func() {
+		p.poDataset = C.GDALCreateCopy(
+			poDriver, cname, src.poDataset, C.FALSE,
+			(**C.char)(unsafe.Pointer(&opts[0])),
+			h.pfnProgress(), h.pProgressData(),
+		)
+	})
 	if p.poDataset == nil {
-		err = fmt.Errorf("gdal: CreateDatasetCopy(%q) failed.", filename)
+		err = cplErrorf(cplErr, "gdal: CreateDatasetCopy(%q) failed", filename)
 		return
 	}
+	if p._Channels > 0 {
+		p.Opt.NoData = nil
+		if nodata, ok := getNoDataValue(C.GDALGetRasterBand(p.poDataset, 1)); ok {
+			p.Opt.NoData = &nodata
+		}
+	}
 
 	return
 }
@@ -310,6 +484,10 @@ func (p *Dataset) Close() error {
 		C.GDALClose(p.poDataset)
 		p.poDataset = nil
 	}
+	if p.vsiFilename != "" {
+		vsiUnlinkAll(p.vsiFilename)
+		p.vsiFilename = ""
+	}
 	return nil
 }
 
@@ -318,7 +496,32 @@ func (p *Dataset) Height() int            { return p._Height }
 func (p *Dataset) Channels() int          { return p._Channels }
 func (p *Dataset) DataType() reflect.Kind { return p._DataType }
 
-func (p *Dataset) SetProjection(projName string) error {
+// SetProjection sets the projection of the dataset.
+//
+// The accepted types of proj are:
+//
+//	string              // the WKT of the projection
+//	*SpatialReference   // exported as WKT, must not be nil
+//
+// Other types (such as an EPSG code of int) return an error.
+func (p *Dataset) SetProjection(proj interface{}) error {
+	var projName string
+	switch proj := proj.(type) {
+	case string:
+		projName = proj
+	case *SpatialReference:
+		if proj == nil {
+			return fmt.Errorf("gdal: Dataset(%q).SetProjection, nil SpatialReference.", p.Filename)
+		}
+		wkt, err := proj.ExportToWkt()
+		if err != nil {
+			return fmt.Errorf("gdal: Dataset(%q).SetProjection failed: %w", p.Filename, err)
+		}
+		projName = wkt
+	default:
+		return fmt.Errorf("gdal: Dataset(%q).SetProjection, unsupported type: %T", p.Filename, proj)
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -329,8 +532,12 @@ func (p *Dataset) SetProjection(projName string) error {
 	cProjName := C.CString(projName)
 	defer C.free(unsafe.Pointer(cProjName))
 
-	if C.GDALSetProjection(p.poDataset, cProjName) != C.CE_None {
-		return fmt.Errorf("gdal: SetProjection(%q) failed.", projName)
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetProjection(p.poDataset, cProjName)
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: SetProjection(%q) failed", projName)
 	}
 	p.Opt.Projection = projName
 	return nil
@@ -348,8 +555,12 @@ func (p *Dataset) SetGeoTransform(transform [6]float64) error {
 	for i := 0; i < len(padfTransform); i++ {
 		padfTransform[i] = C.double(transform[i])
 	}
-	if C.GDALSetGeoTransform(p.poDataset, &padfTransform[0]) != C.CE_None {
-		return fmt.Errorf("gdal: SetGeoTransform(%v) failed.", transform)
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetGeoTransform(p.poDataset, &padfTransform[0])
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: SetGeoTransform(%v) failed", transform)
 	}
 	p.Opt.Transform = transform
 	return nil
@@ -375,14 +586,65 @@ func (p *Dataset) SetGeoTransformX0Y0DxDy(x0, y0, dx, dy float64) error {
 	for i := 0; i < len(padfTransform); i++ {
 		padfTransform[i] = C.double(transform[i])
 	}
-	if C.GDALSetGeoTransform(p.poDataset, &padfTransform[0]) != C.CE_None {
-		return fmt.Errorf("gdal: SetGeoTransform(%v) failed.", transform)
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetGeoTransform(p.poDataset, &padfTransform[0])
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: SetGeoTransform(%v) failed", transform)
 	}
 	p.Opt.Transform = transform
 	return nil
 }
 
+// GetNoDataValue returns the NoData value of the first band, ok is false if it is not set.
+func (p *Dataset) GetNoDataValue() (nodata float64, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p._Channels == 0 {
+		return 0, false
+	}
+	return getNoDataValue(C.GDALGetRasterBand(p.poDataset, 1))
+}
+
+// SetNoDataValue sets the NoData value of all bands.
+func (p *Dataset) SetNoDataValue(nodata float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for nBandId := 0; nBandId < p._Channels; nBandId++ {
+		pBand := C.GDALGetRasterBand(p.poDataset, C.int(nBandId+1))
+		if err := setNoDataValue(pBand, nodata); err != nil {
+			return fmt.Errorf("gdal: Dataset(%q).SetNoDataValue(%v) failed: %w", p.Filename, nodata, err)
+		}
+	}
+	p.Opt.NoData = &nodata
+	return nil
+}
+
+// DeleteNoDataValue removes the NoData value of all bands.
+func (p *Dataset) DeleteNoDataValue() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for nBandId := 0; nBandId < p._Channels; nBandId++ {
+		pBand := C.GDALGetRasterBand(p.poDataset, C.int(nBandId+1))
+		if err := deleteNoDataValue(pBand); err != nil {
+			return fmt.Errorf("gdal: Dataset(%q).DeleteNoDataValue failed: %w", p.Filename, err)
+		}
+	}
+	p.Opt.NoData = nil
+	return nil
+}
+
+// SetResampleType sets the resampling of BuildOverviews, the warp only
+// types (MIN, MAX, MED, Q1, Q3 and SUM) are not supported.
 func (p *Dataset) SetResampleType(resampleType ResampleType) error {
+	if !resampleType.isOverviewResampling() {
+		return fmt.Errorf("gdal: Dataset(%q).SetResampleType, unsupported overview resample type: %s", p.Filename, resampleType.Name())
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -395,7 +657,7 @@ func (p *Dataset) Read(r image.Rectangle) (m image.Image, err error) {
 	defer p.mu.Unlock()
 
 	pix := make([]byte, r.Dx()*r.Dy()*p._Channels*SizeofKind(p._DataType))
-	if err = p.readWithSize(r, r.Dx(), r.Dy(), pix, 0); err != nil {
+	if err = p.readWithSize(r, r.Dx(), r.Dy(), pix, 0, p._DataType, nil); err != nil {
 		return nil, err
 	}
 	m = &MemPImage{
@@ -414,7 +676,7 @@ func (p *Dataset) ReadToSize(r image.Rectangle, size image.Point) (m image.Image
 	defer p.mu.Unlock()
 
 	pix := make([]byte, size.X*size.Y*p._Channels*SizeofKind(p._DataType))
-	if err = p.readWithSize(r, size.X, size.Y, pix, 0); err != nil {
+	if err = p.readWithSize(r, size.X, size.Y, pix, 0, p._DataType, nil); err != nil {
 		return nil, err
 	}
 	m = &MemPImage{
@@ -467,8 +729,10 @@ func (p *Dataset) ReadOverview(idxOverview int, r image.Rectangle) (m image.Imag
 	return
 }
 
-func (p *Dataset) readWithSize(r image.Rectangle, nBufXSize, nBufYSize int, data []byte, stride int) error {
-	pixelSize := SizeofPixel(p._Channels, p._DataType)
+// readWithSize reads the r area of all the bands to the interleaved buffer
+// of nBufXSize x nBufYSize, GDAL converts the band data type to dataType.
+func (p *Dataset) readWithSize(r image.Rectangle, nBufXSize, nBufYSize int, data []byte, stride int, dataType reflect.Kind, h *progressHandle) error {
+	pixelSize := SizeofPixel(p._Channels, dataType)
 
 	if stride == 0 {
 		stride = nBufXSize * pixelSize
@@ -476,6 +740,12 @@ func (p *Dataset) readWithSize(r image.Rectangle, nBufXSize, nBufYSize int, data
 	if n := nBufXSize * pixelSize; stride < n {
 		return fmt.Errorf("gdal: Dataset(%q).read, bad stride: %d", p.Filename, stride)
 	}
+	if nBufXSize <= 0 || nBufYSize <= 0 || p._Channels == 0 {
+		return nil
+	}
+	if n := (nBufYSize-1)*stride + nBufXSize*pixelSize; len(data) < n {
+		return fmt.Errorf("gdal: Dataset(%q).read, buffer too small: %d < %d", p.Filename, len(data), n)
+	}
 
 	if r.Empty() {
 		for y := 0; y < nBufYSize; y++ {
@@ -487,17 +757,21 @@ func (p *Dataset) readWithSize(r image.Rectangle, nBufXSize, nBufYSize int, data
 		return nil
 	}
 
-	data = data[:nBufYSize*stride]
 	for nBandId := 0; nBandId < p._Channels; nBandId++ {
 		pBand := C.GDALGetRasterBand(p.poDataset, C.int(nBandId+1))
-		cErr := C.GDALRasterIO(pBand, C.GF_Read,
-			C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Dx()), C.int(r.Dy()),
-			unsafe.Pointer(&data[nBandId*SizeofKind(p._DataType)]), C.int(nBufXSize), C.int(nBufYSize),
-			gdalDataType(p._DataType), C.int(pixelSize),
-			C.int(stride),
-		)
+		var cErr C.CPLErr
+		cplErr := cplCapture(func() {
+			cErr = C.rasterIO(pBand, C.GF_Read,
+				C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Dx()), C.int(r.Dy()),
+				unsafe.Pointer(&data[nBandId*SizeofKind(dataType)]), C.int(nBufXSize), C.int(nBufYSize),
+				gdalDataType(dataType), C.int(pixelSize),
+				C.int(stride),
+				C.double(nBandId)/C.double(p._Channels), C.double(nBandId+1)/C.double(p._Channels),
+				h.pfnProgress(), h.pProgressData(),
+			)
+		})
 		if cErr != C.CE_None {
-			return fmt.Errorf("gdal: Dataset(%q).read failed.", p.Filename)
+			return cplErrorf(cplErr, "gdal: Dataset(%q).read failed", p.Filename)
 		}
 	}
 	return nil
@@ -507,7 +781,7 @@ func (p *Dataset) ReadToBuf(r image.Rectangle, data []byte, stride int) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	return p.readWithSize(r, r.Dx(), r.Dy(), data, stride)
+	return p.readWithSize(r, r.Dx(), r.Dy(), data, stride, p._DataType, nil)
 }
 
 func (p *Dataset) Write(r image.Rectangle, src image.Image) error {
@@ -519,11 +793,13 @@ func (p *Dataset) Write(r image.Rectangle, src image.Image) error {
 		m = NewMemPImageFrom(src)
 	}
 	r = r.Intersect(m.Bounds())
-	return p.write(r, m.XPix, m.XStride)
+	return p.write(r, m.XPix, m.XStride, p._DataType)
 }
 
-func (p *Dataset) write(r image.Rectangle, data []byte, stride int) error {
-	pixelSize := SizeofPixel(p._Channels, p._DataType)
+// write writes the interleaved buffer of dataType to the r area of all the
+// bands, GDAL converts dataType to the band data type.
+func (p *Dataset) write(r image.Rectangle, data []byte, stride int, dataType reflect.Kind) error {
+	pixelSize := SizeofPixel(p._Channels, dataType)
 
 	if stride == 0 {
 		stride = r.Dx() * pixelSize
@@ -531,18 +807,26 @@ func (p *Dataset) write(r image.Rectangle, data []byte, stride int) error {
 	if n := r.Dx() * pixelSize; stride < n {
 		return fmt.Errorf("gdal: Dataset(%q).writeLevel, bad stride: %d", p.Filename, stride)
 	}
+	if r.Empty() || p._Channels == 0 {
+		return nil
+	}
+	if n := (r.Dy()-1)*stride + r.Dx()*pixelSize; len(data) < n {
+		return fmt.Errorf("gdal: Dataset(%q).writeLevel, buffer too small: %d < %d", p.Filename, len(data), n)
+	}
 
-	data = data[:r.Dy()*stride]
 	for nBandId := 0; nBandId < p._Channels; nBandId++ {
 		pBand := C.GDALGetRasterBand(p.poDataset, C.int(nBandId+1))
-		cErr := C.GDALRasterIO(pBand, C.GF_Write,
-			C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Dx()), C.int(r.Dy()),
-			unsafe.Pointer(&data[nBandId*SizeofKind(p._DataType)]), C.int(r.Dx()), C.int(r.Dy()),
-			gdalDataType(p._DataType), C.int(pixelSize),
-			C.int(stride),
-		)
+		var cErr C.CPLErr
+		cplErr := cplCapture(func() {
+			cErr = C.GDALRasterIO(pBand, C.GF_Write,
+				C.int(r.Min.X), C.int(r.Min.Y), C.int(r.Dx()), C.int(r.Dy()),
+				unsafe.Pointer(&data[nBandId*SizeofKind(dataType)]), C.int(r.Dx()), C.int(r.Dy()),
+				gdalDataType(dataType), C.int(pixelSize),
+				C.int(stride),
+			)
+		})
 		if cErr != C.CE_None {
-			return fmt.Errorf("gdal: Dataset(%q).writeLevel failed.", p.Filename)
+			return cplErrorf(cplErr, "gdal: Dataset(%q).writeLevel failed", p.Filename)
 		}
 	}
 
@@ -553,7 +837,7 @@ func (p *Dataset) WriteFromBuf(r image.Rectangle, data []byte, stride int) error
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	return p.write(r, data, stride)
+	return p.write(r, data, stride, p._DataType)
 }
 
 func (p *Dataset) HasOverviews() bool {
@@ -588,7 +872,7 @@ func (p *Dataset) BuildOverviewsIfNotExists() error {
 		return nil
 	}
 	if overviewList := p.getOverviewList(); len(overviewList) > 0 {
-		if err := p.buildOverviews(overviewList); err != nil {
+		if err := p.buildOverviews(overviewList, nil); err != nil {
 			return err
 		} else {
 			return nil
@@ -605,7 +889,7 @@ func (p *Dataset) BuildOverviews() error {
 		return nil
 	}
 	if overviewList := p.getOverviewList(); len(overviewList) > 0 {
-		if err := p.buildOverviews(overviewList); err != nil {
+		if err := p.buildOverviews(overviewList, nil); err != nil {
 			return err
 		} else {
 			return nil
@@ -614,7 +898,16 @@ func (p *Dataset) BuildOverviews() error {
 	return nil
 }
 
-func (p *Dataset) buildOverviews(overviewList []int) error {
+// BuildOverviewsWithList builds the overviews of the decimation factors,
+// such as []int{2, 4, 8}.
+func (p *Dataset) BuildOverviewsWithList(overviewList []int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.buildOverviews(overviewList, nil)
+}
+
+func (p *Dataset) buildOverviews(overviewList []int, h *progressHandle) error {
 	if len(overviewList) == 0 {
 		return nil
 	}
@@ -642,13 +935,16 @@ func (p *Dataset) buildOverviews(overviewList []int) error {
 		panOverviewList[i] = C.int(overviewList[i])
 	}
 
-	cErr := C.GDALBuildOverviews(p.poDataset, pszResampling,
-		C.int(nOverviews), &panOverviewList[0],
-		0, nil,
-		nil, nil,
-	)
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALBuildOverviews(p.poDataset, pszResampling,
+			C.int(nOverviews), &panOverviewList[0],
+			0, nil,
+			h.pfnProgress(), h.pProgressData(),
+		)
+	})
 	if cErr != C.CE_None {
-		return fmt.Errorf("gdal: Dataset(%q).buildOverviews failed.", p.Filename)
+		return cplErrorf(cplErr, "gdal: Dataset(%q).buildOverviews failed", p.Filename)
 	}
 	return nil
 }
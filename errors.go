@@ -0,0 +1,149 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <cpl_error.h>
+//#include <stdint.h>
+//
+//void pushGoErrorHandler(uintptr_t ctx);
+import "C"
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+)
+
+type ErrorClass int
+
+const (
+	CE_None    ErrorClass = iota // "None"
+	CE_Debug                     // "Debug"
+	CE_Warning                   // "Warning"
+	CE_Failure                   // "Failure"
+	CE_Fatal                     // "Fatal"
+)
+
+func (p ErrorClass) Name() string {
+	switch p {
+	case CE_None:
+		return "None"
+	case CE_Debug:
+		return "Debug"
+	case CE_Warning:
+		return "Warning"
+	case CE_Failure:
+		return "Failure"
+	case CE_Fatal:
+		return "Fatal"
+	}
+	return fmt.Sprintf("ErrorClass(%d)", int(p))
+}
+
+// Error is an error reported by GDAL through CPLError.
+type Error struct {
+	Class ErrorClass
+	Num   int
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gdal: CPLError %s(%d): %s", e.Class.Name(), e.Num, e.Msg)
+}
+
+var (
+	cplLoggerMutex sync.Mutex
+	cplLogger      *log.Logger
+
+	cplStackMutex sync.Mutex
+	cplStackId    uintptr
+	cplStackMap   = make(map[uintptr]*cplErrorStack)
+)
+
+// SetLogger routes the CE_Debug and CE_Warning messages of GDAL to l.
+// If l is nil, the messages are written to stderr by CPLDefaultErrorHandler.
+func SetLogger(l *log.Logger) {
+	cplLoggerMutex.Lock()
+	defer cplLoggerMutex.Unlock()
+	cplLogger = l
+}
+
+// cplErrorStack collects the errors reported by GDAL in the current call.
+type cplErrorStack struct {
+	errs []*Error
+}
+
+// cplCapture runs fn with a CPL error handler bound to the current thread,
+// and returns the last CE_Failure/CE_Fatal error reported by GDAL.
+func cplCapture(fn func()) *Error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	s := new(cplErrorStack)
+
+	cplStackMutex.Lock()
+	cplStackId++
+	id := cplStackId
+	cplStackMap[id] = s
+	cplStackMutex.Unlock()
+
+	defer func() {
+		cplStackMutex.Lock()
+		delete(cplStackMap, id)
+		cplStackMutex.Unlock()
+	}()
+
+	C.CPLErrorReset()
+	C.pushGoErrorHandler(C.uintptr_t(id))
+	defer C.CPLPopErrorHandler()
+
+	fn()
+
+	for i := len(s.errs) - 1; i >= 0; i-- {
+		if s.errs[i].Class >= CE_Failure {
+			return s.errs[i]
+		}
+	}
+	return nil
+}
+
+// cplErrorf formats an error which wraps the *Error reported by GDAL.
+func cplErrorf(e *Error, format string, a ...interface{}) error {
+	if e == nil {
+		return fmt.Errorf(format, a...)
+	}
+	return fmt.Errorf(format+": %w", append(a, e)...)
+}
+
+//export goCPLErrorHandler
+func goCPLErrorHandler(eErrClass C.CPLErr, nErrNo C.int, pszMsg *C.char, ctx C.uintptr_t) {
+	e := &Error{
+		Class: ErrorClass(eErrClass),
+		Num:   int(nErrNo),
+		Msg:   C.GoString(pszMsg),
+	}
+
+	if e.Class >= CE_Failure && ctx != 0 {
+		cplStackMutex.Lock()
+		s := cplStackMap[uintptr(ctx)]
+		cplStackMutex.Unlock()
+
+		if s != nil {
+			s.errs = append(s.errs, e)
+			return
+		}
+	}
+
+	cplLoggerMutex.Lock()
+	l := cplLogger
+	cplLoggerMutex.Unlock()
+
+	if l != nil {
+		l.Printf("gdal: %s %d: %s", e.Class.Name(), e.Num, e.Msg)
+		return
+	}
+
+	C.CPLDefaultErrorHandler(eErrClass, nErrNo, pszMsg)
+}
@@ -0,0 +1,25 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOpenDataset_cplError(t *testing.T) {
+	_, err := OpenDataset("./testdata/not-exists.tiff", GA_ReadOnly)
+	if err == nil {
+		t.Fatal("expect error")
+	}
+
+	var cplErr *Error
+	if !errors.As(err, &cplErr) {
+		t.Fatalf("expect *Error, got %v", err)
+	}
+	if cplErr.Class < CE_Failure || cplErr.Msg == "" {
+		t.Fatalf("bad cplErr: %#v", cplErr)
+	}
+}
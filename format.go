@@ -0,0 +1,36 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"image"
+	"sync"
+)
+
+var registerFormatsOnce sync.Once
+
+// RegisterFormat registers the GDAL decoder for image.Decode, see
+// image.RegisterFormat for the name and magic.
+func RegisterFormat(name, magic string) {
+	image.RegisterFormat(name, magic, Decode, DecodeConfig)
+}
+
+// RegisterFormats registers the GDAL decoder for the TIFF (include BigTIFF),
+// JPEG2000 and WebP formats. It is safe to call it more than once.
+//
+// The formats are not registered by default, because image.Decode uses the
+// first matched format, and it may conflict with other decoders (such as
+// golang.org/x/image/tiff).
+func RegisterFormats() {
+	registerFormatsOnce.Do(func() {
+		RegisterFormat("tiff", "II*\x00")
+		RegisterFormat("tiff", "MM\x00*")
+		RegisterFormat("bigtiff", "II+\x00")
+		RegisterFormat("bigtiff", "MM\x00+")
+		RegisterFormat("jp2", "\x00\x00\x00\x0cjP  \r\n\x87\n")
+		RegisterFormat("j2k", "\xff\x4f\xff\x51")
+		RegisterFormat("webp", "RIFF????WEBP")
+	})
+}
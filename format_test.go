@@ -0,0 +1,74 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	data := tbLoadData(t, "video-001.tiff")
+
+	cfg, err := DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 150 || cfg.Height != 103 {
+		t.Fatalf("cfg: %v", cfg)
+	}
+
+	m, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := m.Bounds(); b.Dx() != 150 || b.Dy() != 103 {
+		t.Fatalf("bounds: %v", b)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	m0, err := Load("./testdata/video-001.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, m0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("II*\x00")) && !bytes.HasPrefix(buf.Bytes(), []byte("MM\x00*")) {
+		t.Fatal("not a tiff file")
+	}
+
+	m1, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, _ := AsMemPImage(m0)
+	p1, _ := AsMemPImage(m1)
+	if !bytes.Equal(p0.XPix, p1.XPix) {
+		t.Fatal("pixels not equal")
+	}
+}
+
+func TestRegisterFormats(t *testing.T) {
+	RegisterFormats()
+	RegisterFormats()
+
+	for _, filename := range []string{"video-001.tiff", "video-001.webp"} {
+		m, format, err := image.Decode(bytes.NewReader(tbLoadData(t, filename)))
+		if err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		if format != "tiff" && format != "webp" {
+			t.Fatalf("%s: format = %q", filename, format)
+		}
+		if b := m.Bounds(); b.Dx() != 150 || b.Dy() != 103 {
+			t.Fatalf("%s: bounds: %v", filename, b)
+		}
+	}
+}
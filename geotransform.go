@@ -0,0 +1,162 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+import "C"
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// GeoRect is a rectangle in the georeferenced (world) coordinates.
+type GeoRect struct {
+	MinX, MinY float64
+	MaxX, MaxY float64
+}
+
+func (r GeoRect) Dx() float64 { return r.MaxX - r.MinX }
+func (r GeoRect) Dy() float64 { return r.MaxY - r.MinY }
+
+func (r GeoRect) Empty() bool {
+	return r.MinX >= r.MaxX || r.MinY >= r.MaxY
+}
+
+// Intersect returns the largest rectangle contained by both r and s.
+func (r GeoRect) Intersect(s GeoRect) GeoRect {
+	r.MinX = math.Max(r.MinX, s.MinX)
+	r.MinY = math.Max(r.MinY, s.MinY)
+	r.MaxX = math.Min(r.MaxX, s.MaxX)
+	r.MaxY = math.Min(r.MaxY, s.MaxY)
+	if r.Empty() {
+		return GeoRect{}
+	}
+	return r
+}
+
+// Union returns the smallest rectangle that contains both r and s.
+func (r GeoRect) Union(s GeoRect) GeoRect {
+	if r.Empty() {
+		return s
+	}
+	if s.Empty() {
+		return r
+	}
+	r.MinX = math.Min(r.MinX, s.MinX)
+	r.MinY = math.Min(r.MinY, s.MinY)
+	r.MaxX = math.Max(r.MaxX, s.MaxX)
+	r.MaxY = math.Max(r.MaxY, s.MaxY)
+	return r
+}
+
+// PixelToGeo converts the pixel/line coordinates to the georeferenced coordinates.
+//
+//	gx = Transform[0] + x*Transform[1] + y*Transform[2]
+//	gy = Transform[3] + x*Transform[4] + y*Transform[5]
+func (p *Dataset) PixelToGeo(x, y float64) (gx, gy float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return applyGeoTransform(&p.Opt.Transform, x, y)
+}
+
+// GeoToPixel converts the georeferenced coordinates to the pixel/line coordinates.
+func (p *Dataset) GeoToPixel(gx, gy float64) (x, y float64, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inv, err := invGeoTransform(&p.Opt.Transform)
+	if err != nil {
+		return 0, 0, fmt.Errorf("gdal: Dataset(%q).GeoToPixel failed: %w", p.Filename, err)
+	}
+	x, y = applyGeoTransform(&inv, gx, gy)
+	return
+}
+
+// GeoBounds returns the extent of the dataset in the georeferenced coordinates.
+func (p *Dataset) GeoBounds() GeoRect {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.geoBounds(image.Rect(0, 0, p._Width, p._Height))
+}
+
+// PixelRectToGeoRect returns the extent of r in the georeferenced coordinates.
+func (p *Dataset) PixelRectToGeoRect(r image.Rectangle) GeoRect {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.geoBounds(r)
+}
+
+// GeoRectToPixelRect returns the smallest pixel rectangle which covers the
+// georeferenced rectangle, clipped to the bounds of the dataset.
+//
+// The result can be passed to Dataset.Read directly.
+func (p *Dataset) GeoRectToPixelRect(r GeoRect) (image.Rectangle, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inv, err := invGeoTransform(&p.Opt.Transform)
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("gdal: Dataset(%q).GeoRectToPixelRect failed: %w", p.Filename, err)
+	}
+
+	minX, minY := math.Inf(+1), math.Inf(+1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, pt := range [][2]float64{
+		{r.MinX, r.MinY}, {r.MaxX, r.MinY},
+		{r.MinX, r.MaxY}, {r.MaxX, r.MaxY},
+	} {
+		x, y := applyGeoTransform(&inv, pt[0], pt[1])
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	// avoid float error: 99.9999999 => 100
+	const eps = 1e-6
+	rect := image.Rect(
+		int(math.Floor(minX+eps)), int(math.Floor(minY+eps)),
+		int(math.Ceil(maxX-eps)), int(math.Ceil(maxY-eps)),
+	)
+	return rect.Intersect(image.Rect(0, 0, p._Width, p._Height)), nil
+}
+
+func (p *Dataset) geoBounds(r image.Rectangle) GeoRect {
+	b := GeoRect{
+		MinX: math.Inf(+1), MinY: math.Inf(+1),
+		MaxX: math.Inf(-1), MaxY: math.Inf(-1),
+	}
+	for _, pt := range []image.Point{
+		r.Min, image.Pt(r.Max.X, r.Min.Y),
+		image.Pt(r.Min.X, r.Max.Y), r.Max,
+	} {
+		gx, gy := applyGeoTransform(&p.Opt.Transform, float64(pt.X), float64(pt.Y))
+		b.MinX, b.MaxX = math.Min(b.MinX, gx), math.Max(b.MaxX, gx)
+		b.MinY, b.MaxY = math.Min(b.MinY, gy), math.Max(b.MaxY, gy)
+	}
+	return b
+}
+
+func applyGeoTransform(transform *[6]float64, x, y float64) (gx, gy float64) {
+	gx = transform[0] + x*transform[1] + y*transform[2]
+	gy = transform[3] + x*transform[4] + y*transform[5]
+	return
+}
+
+func invGeoTransform(transform *[6]float64) (inv [6]float64, err error) {
+	var gtIn, gtOut [6]C.double
+	for i := 0; i < len(gtIn); i++ {
+		gtIn[i] = C.double(transform[i])
+	}
+	if C.GDALInvGeoTransform(&gtIn[0], &gtOut[0]) == 0 {
+		return inv, fmt.Errorf("gdal: GDALInvGeoTransform(%v), transform is not invertible.", *transform)
+	}
+	for i := 0; i < len(gtOut); i++ {
+		inv[i] = float64(gtOut[i])
+	}
+	return inv, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDataset_GeoToPixel(t *testing.T) {
+	f, err := CreateDataset("", 400, 300, 1, reflect.Uint8, &Options{
+		DriverName: "MEM",
+		Transform:  [6]float64{1000, 0.5, 0, 2000, 0, -0.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if gx, gy := f.PixelToGeo(10, 20); gx != 1005 || gy != 1990 {
+		t.Fatalf("PixelToGeo: got = (%v, %v)", gx, gy)
+	}
+	x, y, err := f.GeoToPixel(1005, 1990)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x-10) > 1e-9 || math.Abs(y-20) > 1e-9 {
+		t.Fatalf("GeoToPixel: got = (%v, %v)", x, y)
+	}
+
+	b := f.GeoBounds()
+	if expect := (GeoRect{MinX: 1000, MinY: 1850, MaxX: 1200, MaxY: 2000}); b != expect {
+		t.Fatalf("GeoBounds: expect = %v, got = %v", expect, b)
+	}
+
+	r, err := f.GeoRectToPixelRect(GeoRect{MinX: 1005, MinY: 1900, MaxX: 1300, MaxY: 1990})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := image.Rect(10, 20, 400, 200); r != expect {
+		t.Fatalf("GeoRectToPixelRect: expect = %v, got = %v", expect, r)
+	}
+}
@@ -48,6 +48,7 @@ type MemPImage struct {
 	XDataType  reflect.Kind
 	XPix       PixSlice
 	XStride    int
+	XNoData    *float64 // optional NoData value
 }
 
 func NewMemPImage(r image.Rectangle, channels int, dataType reflect.Kind) *MemPImage {
@@ -236,6 +237,14 @@ func (p *MemPImage) Stride() int {
 	return p.XStride
 }
 
+// NoData returns the NoData value of the image, ok is false if it is not set.
+func (p *MemPImage) NoData() (nodata float64, ok bool) {
+	if p.XNoData == nil {
+		return 0, false
+	}
+	return *p.XNoData, true
+}
+
 func (p *MemPImage) ColorModel() color.Model {
 	return ColorModel(p.XChannels, p.XDataType)
 }
@@ -303,6 +312,7 @@ func (p *MemPImage) SubImage(r image.Rectangle) image.Image {
 		XDataType: p.XDataType,
 		XPix:      p.XPix[i:],
 		XStride:   p.XStride,
+		XNoData:   p.XNoData,
 	}
 }
 
@@ -145,8 +145,8 @@ func (d PixSlice) Complex64s() (v []complex64) {
 	h0 := (*reflect.SliceHeader)(unsafe.Pointer(&d))
 	h1 := (*reflect.SliceHeader)(unsafe.Pointer(&v))
 
-	h1.Cap = h0.Cap / 16
-	h1.Len = h0.Len / 16
+	h1.Cap = h0.Cap / 8
+	h1.Len = h0.Len / 8
 	h1.Data = h0.Data
 	return
 }
@@ -155,8 +155,8 @@ func (d PixSlice) Complex128s() (v []complex128) {
 	h0 := (*reflect.SliceHeader)(unsafe.Pointer(&d))
 	h1 := (*reflect.SliceHeader)(unsafe.Pointer(&v))
 
-	h1.Cap = h0.Cap / 32
-	h1.Len = h0.Len / 32
+	h1.Cap = h0.Cap / 16
+	h1.Len = h0.Len / 16
 	h1.Data = h0.Data
 	return
 }
@@ -6,8 +6,31 @@ package gdal
 
 /*
 #include <gdal.h>
+#include <cpl_error.h>
+#include <stdint.h>
+
+extern void goCPLErrorHandler(CPLErr eErrClass, int nErrNo, char *pszMsg, uintptr_t ctx);
+
+static void CPL_STDCALL cplErrorHandler(CPLErr eErrClass, int nErrNo, const char *pszMsg) {
+	goCPLErrorHandler(eErrClass, nErrNo, (char*)pszMsg, (uintptr_t)CPLGetErrorHandlerUserData());
+}
+
+void pushGoErrorHandler(uintptr_t ctx) {
+	CPLPushErrorHandlerEx(cplErrorHandler, (void*)ctx);
+}
+
+extern int goProgressFunc(double dfComplete, char *pszMessage, uintptr_t ctx);
+
+int CPL_STDCALL cplProgress(double dfComplete, const char *pszMessage, void *pProgressArg) {
+	return goProgressFunc(dfComplete, (char*)pszMessage, (uintptr_t)pProgressArg);
+}
+
+void *progressArg(uintptr_t ctx) {
+	return (void*)ctx;
+}
 
 void initGDAL() {
+	CPLSetErrorHandler(cplErrorHandler);
 	GDALAllRegister();
 }
 */
@@ -0,0 +1,191 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+import "C"
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"strings"
+)
+
+type MaskFlags int
+
+const (
+	GMF_AllValid   MaskFlags = C.GMF_ALL_VALID   // "AllValid", no invalid pixels, the mask is all 255
+	GMF_PerDataset MaskFlags = C.GMF_PER_DATASET // "PerDataset", the mask is shared by all the bands
+	GMF_Alpha      MaskFlags = C.GMF_ALPHA       // "Alpha", the mask is an alpha band
+	GMF_NoData     MaskFlags = C.GMF_NODATA      // "NoData", the mask is derived from the NoData value
+)
+
+func (p MaskFlags) Name() string {
+	var names []string
+	for _, v := range []struct {
+		flag MaskFlags
+		name string
+	}{
+		{GMF_AllValid, "AllValid"},
+		{GMF_PerDataset, "PerDataset"},
+		{GMF_Alpha, "Alpha"},
+		{GMF_NoData, "NoData"},
+	} {
+		if p&v.flag != 0 {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) == 0 {
+		return "None"
+	}
+	return strings.Join(names, "|")
+}
+
+// MaskFlags returns the flags of the mask band.
+//
+// The mask may be a real mask (such as the internal mask of TIFF, the
+// flags is 0 or GMF_PerDataset), an alpha band, or derived from NoData.
+func (p *RasterBand) MaskFlags() MaskFlags {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return MaskFlags(C.GDALGetMaskFlags(p.poBand))
+}
+
+// CreateMask creates a mask band for this band, flags is 0 or
+// GMF_PerDataset (see Dataset.CreateMask).
+func (p *RasterBand) CreateMask(flags MaskFlags) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALCreateMaskBand(p.poBand, C.int(flags))
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: RasterBand(%q, %d).CreateMask(%s) failed", p.ds.Filename, p.index, flags.Name())
+	}
+	return nil
+}
+
+// ReadMask reads the r area of the mask band, 0 is invalid (transparent)
+// and 255 is valid (opaque).
+func (p *RasterBand) ReadMask(r image.Rectangle) (m *image.Alpha, err error) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	m = image.NewAlpha(r)
+	if err = p.maskBand().rasterIOBuf(C.GF_Read, r, r.Size(), m.Pix, reflect.Uint8); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// WriteMask writes the r area of the mask band, the mask must be created
+// by CreateMask at first.
+func (p *RasterBand) WriteMask(r image.Rectangle, m *image.Alpha) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return p.maskBand().writeAlpha(r, m)
+}
+
+func (p *RasterBand) maskBand() *RasterBand {
+	return &RasterBand{
+		ds:     p.ds,
+		index:  p.index,
+		poBand: C.GDALGetMaskBand(p.poBand),
+	}
+}
+
+// writeAlpha writes the r area of m to the band.
+func (p *RasterBand) writeAlpha(r image.Rectangle, m *image.Alpha) error {
+	r = r.Intersect(m.Rect)
+	if r.Empty() {
+		return nil
+	}
+	data := m.Pix[m.PixOffset(r.Min.X, r.Min.Y):]
+	if m.Stride != r.Dx() {
+		data = make([]byte, r.Dx()*r.Dy())
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			copy(data[(y-r.Min.Y)*r.Dx():], m.Pix[m.PixOffset(r.Min.X, y):][:r.Dx()])
+		}
+	}
+	return p.rasterIOBuf(C.GF_Write, r, r.Size(), data, reflect.Uint8)
+}
+
+// MaskFlags returns the mask flags of the first band.
+func (p *Dataset) MaskFlags() MaskFlags {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p._Channels == 0 {
+		return 0
+	}
+	return MaskFlags(C.GDALGetMaskFlags(C.GDALGetRasterBand(p.poDataset, 1)))
+}
+
+// CreateMask creates a mask band shared by all the bands (GMF_PerDataset),
+// such as the internal mask of GeoTIFF.
+func (p *Dataset) CreateMask() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.createMask()
+}
+
+func (p *Dataset) createMask() error {
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALCreateDatasetMaskBand(p.poDataset, C.GMF_PER_DATASET)
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: Dataset(%q).CreateMask failed", p.Filename)
+	}
+	return nil
+}
+
+// ReadMask reads the r area of the mask of the first band, see
+// RasterBand.ReadMask.
+func (p *Dataset) ReadMask(r image.Rectangle) (*image.Alpha, error) {
+	band, err := p.Band(0)
+	if err != nil {
+		return nil, err
+	}
+	return band.ReadMask(r)
+}
+
+// ReadWithMask reads the r area and its mask, the pixels whose mask is 0
+// are invalid (such as NoData or outside of the alpha band).
+func (p *Dataset) ReadWithMask(r image.Rectangle) (m image.Image, mask *image.Alpha, err error) {
+	if m, err = p.Read(r); err != nil {
+		return nil, nil, err
+	}
+	if mask, err = p.ReadMask(r); err != nil {
+		return nil, nil, err
+	}
+	return m, mask, nil
+}
+
+// WriteMask writes the r area of the per-dataset mask, the mask is created
+// if the dataset has no per-dataset mask.
+func (p *Dataset) WriteMask(r image.Rectangle, m *image.Alpha) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p._Channels == 0 {
+		return fmt.Errorf("gdal: Dataset(%q).WriteMask, no bands.", p.Filename)
+	}
+
+	poBand := C.GDALGetRasterBand(p.poDataset, 1)
+	if MaskFlags(C.GDALGetMaskFlags(poBand))&GMF_PerDataset == 0 {
+		if err := p.createMask(); err != nil {
+			return err
+		}
+	}
+
+	band := &RasterBand{ds: p, index: 0, poBand: poBand}
+	return band.maskBand().writeAlpha(r, m)
+}
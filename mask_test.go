@@ -0,0 +1,88 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMaskFlags_Name(t *testing.T) {
+	if s := (GMF_PerDataset | GMF_Alpha).Name(); s != "PerDataset|Alpha" {
+		t.Fatal(s)
+	}
+	if s := MaskFlags(0).Name(); s != "None" {
+		t.Fatal(s)
+	}
+}
+
+func TestDataset_MaskNoData(t *testing.T) {
+	nodata := 0.0
+	f, err := CreateDataset("", 4, 1, 1, reflect.Uint8, &Options{DriverName: "MEM", NoData: &nodata})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if flags := f.MaskFlags(); flags != GMF_NoData {
+		t.Fatalf("flags: %s", flags.Name())
+	}
+	if err := f.WriteFromBuf(image.Rect(0, 0, 4, 1), []byte{0, 1, 0, 2}, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	_, mask, err := f.ReadWithMask(image.Rect(0, 0, 4, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mask.Pix, []byte{0, 255, 0, 255}) {
+		t.Fatalf("mask: %v", mask.Pix)
+	}
+}
+
+func TestDataset_WriteMask(t *testing.T) {
+	const filename = "z_test_mask.tiff"
+	defer os.Remove(filename)
+	defer os.Remove(filename + ".msk")
+
+	f, err := CreateDataset(filename, 4, 2, 3, reflect.Uint8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags := f.MaskFlags(); flags != GMF_AllValid {
+		t.Fatalf("flags: %s", flags.Name())
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, 4, 2))
+	copy(mask.Pix, []byte{0, 255, 255, 0, 255, 0, 0, 255})
+	if err := f.WriteMask(mask.Rect, mask); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	if err := f.WriteMask(image.Rect(8, 8, 10, 10), mask); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if f, err = OpenDataset(filename, GA_ReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if flags := f.MaskFlags(); flags != GMF_PerDataset {
+		t.Fatalf("flags: %s", flags.Name())
+	}
+	got, err := f.ReadMask(image.Rect(1, 0, 4, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Pix, []byte{255, 255, 0, 0, 0, 255}) {
+		t.Fatalf("mask: %v", got.Pix)
+	}
+}
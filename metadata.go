@@ -0,0 +1,257 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+//#include <cpl_string.h>
+//#include <stdlib.h>
+import "C"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// Well known metadata domains.
+const (
+	MetadataDomain_Default        = ""
+	MetadataDomain_ImageStructure = "IMAGE_STRUCTURE"
+	MetadataDomain_Subdatasets    = "SUBDATASETS"
+	MetadataDomain_RPC            = "RPC"
+	MetadataDomain_GeoLocation    = "GEOLOCATION"
+	MetadataDomain_EXIF           = "EXIF"
+)
+
+// MetadataDomains returns the metadata domains of the dataset.
+func (p *Dataset) MetadataDomains() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return getMetadataDomains(C.GDALMajorObjectH(p.poDataset))
+}
+
+// GetMetadata returns the metadata of the domain, "" is the default domain.
+//
+// The "xml:" domains hold a single XML document, which is returned as the
+// value of the domain name, e.g. m["xml:XMP"].
+func (p *Dataset) GetMetadata(domain string) map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return getMetadata(C.GDALMajorObjectH(p.poDataset), domain)
+}
+
+// SetMetadata replaces the metadata of the domain.
+//
+// For the "xml:" domains, the XML document is the value of the domain name.
+func (p *Dataset) SetMetadata(domain string, metadata map[string]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := setMetadata(C.GDALMajorObjectH(p.poDataset), domain, metadata); err != nil {
+		return fmt.Errorf("gdal: Dataset(%q).SetMetadata(%q) failed: %w", p.Filename, domain, err)
+	}
+	return nil
+}
+
+// GetMetadataItem returns the value of the name in the domain, ok is false if it is not set.
+func (p *Dataset) GetMetadataItem(name, domain string) (value string, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return getMetadataItem(C.GDALMajorObjectH(p.poDataset), name, domain)
+}
+
+func (p *Dataset) SetMetadataItem(name, value, domain string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := setMetadataItem(C.GDALMajorObjectH(p.poDataset), name, value, domain); err != nil {
+		return fmt.Errorf("gdal: Dataset(%q).SetMetadataItem(%q, %q) failed: %w", p.Filename, name, domain, err)
+	}
+	return nil
+}
+
+// MetadataDomains returns the metadata domains of the band.
+func (p *RasterBand) MetadataDomains() []string {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return getMetadataDomains(C.GDALMajorObjectH(p.poBand))
+}
+
+// GetMetadata returns the metadata of the domain, "" is the default domain.
+func (p *RasterBand) GetMetadata(domain string) map[string]string {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return getMetadata(C.GDALMajorObjectH(p.poBand), domain)
+}
+
+// SetMetadata replaces the metadata of the domain.
+func (p *RasterBand) SetMetadata(domain string, metadata map[string]string) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	if err := setMetadata(C.GDALMajorObjectH(p.poBand), domain, metadata); err != nil {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).SetMetadata(%q) failed: %w", p.ds.Filename, p.index, domain, err)
+	}
+	return nil
+}
+
+// GetMetadataItem returns the value of the name in the domain, ok is false if it is not set.
+func (p *RasterBand) GetMetadataItem(name, domain string) (value string, ok bool) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	return getMetadataItem(C.GDALMajorObjectH(p.poBand), name, domain)
+}
+
+func (p *RasterBand) SetMetadataItem(name, value, domain string) error {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	if err := setMetadataItem(C.GDALMajorObjectH(p.poBand), name, value, domain); err != nil {
+		return fmt.Errorf("gdal: RasterBand(%q, %d).SetMetadataItem(%q, %q) failed: %w", p.ds.Filename, p.index, name, domain, err)
+	}
+	return nil
+}
+
+func getMetadataDomains(hObject C.GDALMajorObjectH) []string {
+	papszDomains := C.GDALGetMetadataDomainList(hObject)
+	defer C.CSLDestroy(papszDomains)
+
+	return goStringList(papszDomains)
+}
+
+func getMetadata(hObject C.GDALMajorObjectH, domain string) map[string]string {
+	cDomain := C.CString(domain)
+	defer C.free(unsafe.Pointer(cDomain))
+
+	metadata := make(map[string]string)
+	if isXMLMetadataDomain(domain) {
+		if ss := goStringList(C.GDALGetMetadata(hObject, cDomain)); len(ss) > 0 {
+			metadata[domain] = ss[0]
+		}
+		return metadata
+	}
+	for _, s := range goStringList(C.GDALGetMetadata(hObject, cDomain)) {
+		if i := strings.IndexByte(s, '='); i >= 0 {
+			metadata[s[:i]] = s[i+1:]
+		} else {
+			metadata[s] = ""
+		}
+	}
+	return metadata
+}
+
+// isXMLMetadataDomain reports whether the domain holds a single XML document
+// instead of name=value pairs.
+func isXMLMetadataDomain(domain string) bool {
+	return strings.HasPrefix(domain, "xml:")
+}
+
+func setMetadata(hObject C.GDALMajorObjectH, domain string, metadata map[string]string) error {
+	cDomain := C.CString(domain)
+	defer C.free(unsafe.Pointer(cDomain))
+
+	var papszMetadata **C.char
+	if isXMLMetadataDomain(domain) {
+		if doc, ok := metadata[domain]; ok {
+			papszMetadata = cStringArray([]string{doc})
+		}
+	} else {
+		papszMetadata = cStringList(metadata)
+	}
+	defer C.CSLDestroy(papszMetadata)
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetMetadata(hObject, papszMetadata, cDomain)
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: GDALSetMetadata(%q) failed", domain)
+	}
+	return nil
+}
+
+func getMetadataItem(hObject C.GDALMajorObjectH, name, domain string) (value string, ok bool) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cDomain := C.CString(domain)
+	defer C.free(unsafe.Pointer(cDomain))
+
+	pszValue := C.GDALGetMetadataItem(hObject, cName, cDomain)
+	if pszValue == nil {
+		return "", false
+	}
+	return C.GoString(pszValue), true
+}
+
+func setMetadataItem(hObject C.GDALMajorObjectH, name, value, domain string) error {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cValue := C.CString(value)
+	defer C.free(unsafe.Pointer(cValue))
+	cDomain := C.CString(domain)
+	defer C.free(unsafe.Pointer(cDomain))
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALSetMetadataItem(hObject, cName, cValue, cDomain)
+	})
+	if cErr != C.CE_None {
+		return cplErrorf(cplErr, "gdal: GDALSetMetadataItem(%q, %q) failed", name, domain)
+	}
+	return nil
+}
+
+// goStringList converts a NULL terminated C string list to []string.
+func goStringList(papszStrList **C.char) []string {
+	if papszStrList == nil {
+		return nil
+	}
+	n := int(C.CSLCount(papszStrList))
+	list := (*[1 << 28]*C.char)(unsafe.Pointer(papszStrList))[:n:n]
+
+	ss := make([]string, n)
+	for i := 0; i < n; i++ {
+		ss[i] = C.GoString(list[i])
+	}
+	return ss
+}
+
+// cStringList converts a map to a "NAME=VALUE" C string list in key order,
+// the result must be freed by CSLDestroy.
+func cStringList(m map[string]string) **C.char {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var papszStrList **C.char
+	for _, k := range keys {
+		cName := C.CString(k)
+		cValue := C.CString(m[k])
+		papszStrList = C.CSLSetNameValue(papszStrList, cName, cValue)
+		C.free(unsafe.Pointer(cName))
+		C.free(unsafe.Pointer(cValue))
+	}
+	return papszStrList
+}
+
+// cStringArray converts []string to a NULL terminated C string list,
+// the result must be freed by CSLDestroy.
+func cStringArray(ss []string) **C.char {
+	var papszStrList **C.char
+	for _, s := range ss {
+		cs := C.CString(s)
+		papszStrList = C.CSLAddString(papszStrList, cs)
+		C.free(unsafe.Pointer(cs))
+	}
+	return papszStrList
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDataset_GetMetadata(t *testing.T) {
+	f, err := OpenDataset("./testdata/lena512color.jpeg.tiff", GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	md := f.GetMetadata(MetadataDomain_ImageStructure)
+	if v := md["COMPRESSION"]; v != "JPEG" && v != "YCbCr JPEG" {
+		t.Fatalf("COMPRESSION: %q", v)
+	}
+	if v, ok := f.GetMetadataItem("INTERLEAVE", MetadataDomain_ImageStructure); !ok || v == "" {
+		t.Fatalf("INTERLEAVE: %q(%v)", v, ok)
+	}
+}
+
+func TestDataset_GetMetadata_xml(t *testing.T) {
+	f, err := CreateDataset("", 1, 1, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	const domain = "xml:test"
+	const doc = `<root a="1">x=y</root>`
+	if err := f.SetMetadata(domain, map[string]string{domain: doc}); err != nil {
+		t.Fatal(err)
+	}
+	md := f.GetMetadata(domain)
+	if len(md) != 1 || md[domain] != doc {
+		t.Fatalf("expect = %q, got = %q", doc, md)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DatasetPoolOptions are the options of OpenDatasetPool.
+type DatasetPoolOptions struct {
+	MaxSize      int           // max number of opened handles, 0 means runtime.NumCPU()
+	IdleTimeout  time.Duration // close the handles idle longer than it, 0 means never
+	ResampleType ResampleType  // resample type of the handles
+}
+
+// DatasetPool opens several read only handles of the same file, and
+// dispatches the concurrent reads across them.
+//
+// The GDAL handles are not thread-safe, one handle is only used by one
+// goroutine at the same time. The handles are opened on demand and closed
+// after IdleTimeout.
+type DatasetPool struct {
+	Filename string
+	Opt      *Options // shared metadata of the handles, read only
+
+	_Width    int
+	_Height   int
+	_Channels int
+	_DataType reflect.Kind
+
+	opt    DatasetPoolOptions
+	sem    chan struct{} // bounded size
+	mu     sync.Mutex
+	idle   []*pooledDataset
+	closed bool
+	done   chan struct{}
+}
+
+type pooledDataset struct {
+	ds       *Dataset
+	lastUsed time.Time
+}
+
+// OpenDatasetPool opens the first handle of filename, which is used to check
+// the file and to read the shared metadata.
+func OpenDatasetPool(filename string, opt *DatasetPoolOptions) (p *DatasetPool, err error) {
+	p = &DatasetPool{
+		Filename: filename,
+		done:     make(chan struct{}),
+	}
+	if opt != nil {
+		p.opt = *opt
+	}
+	if p.opt.MaxSize <= 0 {
+		p.opt.MaxSize = runtime.NumCPU()
+	}
+	p.sem = make(chan struct{}, p.opt.MaxSize)
+
+	ds, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+
+	p.Opt = new(Options)
+	*p.Opt = *ds.Opt
+	p._Width = ds._Width
+	p._Height = ds._Height
+	p._Channels = ds._Channels
+	p._DataType = ds._DataType
+
+	p.idle = append(p.idle, &pooledDataset{ds: ds, lastUsed: time.Now()})
+
+	if p.opt.IdleTimeout > 0 {
+		go p.evictLoop()
+	}
+	return p, nil
+}
+
+func (p *DatasetPool) Width() int             { return p._Width }
+func (p *DatasetPool) Height() int            { return p._Height }
+func (p *DatasetPool) Channels() int          { return p._Channels }
+func (p *DatasetPool) DataType() reflect.Kind { return p._DataType }
+
+// Get returns an idle handle (or opens a new one), it blocks if MaxSize
+// handles are in use. The handle must be returned by Put.
+func (p *DatasetPool) Get() (*Dataset, error) {
+	p.sem <- struct{}{}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.sem
+		return nil, fmt.Errorf("gdal: DatasetPool(%q).Get, pool closed.", p.Filename)
+	}
+	if n := len(p.idle); n > 0 {
+		ds := p.idle[n-1].ds
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return ds, nil
+	}
+	p.mu.Unlock()
+
+	ds, err := p.open()
+	if err != nil {
+		<-p.sem
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Put returns the handle taken by Get to the pool.
+func (p *DatasetPool) Put(ds *Dataset) {
+	defer func() { <-p.sem }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		ds.Close()
+		return
+	}
+	p.idle = append(p.idle, &pooledDataset{ds: ds, lastUsed: time.Now()})
+}
+
+// Do calls fn with a handle of the pool.
+func (p *DatasetPool) Do(fn func(ds *Dataset) error) error {
+	ds, err := p.Get()
+	if err != nil {
+		return err
+	}
+	defer p.Put(ds)
+
+	return fn(ds)
+}
+
+func (p *DatasetPool) Read(r image.Rectangle) (m image.Image, err error) {
+	err = p.Do(func(ds *Dataset) error {
+		m, err = ds.Read(r)
+		return err
+	})
+	return
+}
+
+func (p *DatasetPool) ReadToSize(r image.Rectangle, size image.Point) (m image.Image, err error) {
+	err = p.Do(func(ds *Dataset) error {
+		m, err = ds.ReadToSize(r, size)
+		return err
+	})
+	return
+}
+
+func (p *DatasetPool) ReadToBuf(r image.Rectangle, data []byte, stride int) error {
+	return p.Do(func(ds *Dataset) error {
+		return ds.ReadToBuf(r, data, stride)
+	})
+}
+
+func (p *DatasetPool) ReadOverview(idxOverview int, r image.Rectangle) (m image.Image, err error) {
+	err = p.Do(func(ds *Dataset) error {
+		m, err = ds.ReadOverview(idxOverview, r)
+		return err
+	})
+	return
+}
+
+// Close closes the idle handles, the handles in use are closed by Put.
+func (p *DatasetPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+
+	for _, v := range p.idle {
+		v.ds.Close()
+	}
+	p.idle = nil
+	return nil
+}
+
+func (p *DatasetPool) open() (*Dataset, error) {
+	ds, err := OpenDataset(p.Filename, GA_ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	if p.opt.ResampleType != ResampleType_Nil {
+		if err := ds.SetResampleType(p.opt.ResampleType); err != nil {
+			ds.Close()
+			return nil, err
+		}
+	}
+	return ds, nil
+}
+
+func (p *DatasetPool) evictLoop() {
+	ticker := time.NewTicker(p.opt.IdleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.evict(now)
+		}
+	}
+}
+
+// evict closes the handles idle longer than IdleTimeout.
+func (p *DatasetPool) evict(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// the idle list is ordered by lastUsed
+	n := 0
+	for n < len(p.idle) && now.Sub(p.idle[n].lastUsed) >= p.opt.IdleTimeout {
+		p.idle[n].ds.Close()
+		n++
+	}
+	p.idle = append(p.idle[:0], p.idle[n:]...)
+}
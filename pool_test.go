@@ -0,0 +1,78 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDatasetPool_Read(t *testing.T) {
+	const filename = "./testdata/lena512color.tiff"
+
+	expect, err := LoadImage(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := OpenDatasetPool(filename, &DatasetPoolOptions{MaxSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if p.Width() != expect.Bounds().Dx() || p.Height() != expect.Bounds().Dy() {
+		t.Fatalf("bad size: %dx%d", p.Width(), p.Height())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			r := image.Rect(0, i*32, p.Width(), (i+1)*32)
+			m, err := p.Read(r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.Equal(m.(*MemPImage).XPix, expect.SubImage(r).(*MemPImage).XPix[:len(m.(*MemPImage).XPix)]) {
+				t.Errorf("%v: pixels not equal", r)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(p.idle); n < 1 || n > 4 {
+		t.Fatalf("bad idle size: %d", n)
+	}
+}
+
+func TestDatasetPool_evict(t *testing.T) {
+	p, err := OpenDatasetPool("./testdata/video-001.tiff", &DatasetPoolOptions{
+		MaxSize:     2,
+		IdleTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	p.evict(time.Now().Add(2 * time.Minute))
+	if n := len(p.idle); n != 0 {
+		t.Fatalf("expect = 0, got = %d", n)
+	}
+
+	if _, err := p.Read(image.Rect(0, 0, 10, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(p.idle); n != 1 {
+		t.Fatalf("expect = 1, got = %d", n)
+	}
+}
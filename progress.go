@@ -0,0 +1,191 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+//#include <stdint.h>
+//
+//int CPL_STDCALL cplProgress(double dfComplete, const char *pszMessage, void *pProgressArg);
+//void *progressArg(uintptr_t ctx);
+import "C"
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+	"unsafe"
+)
+
+// ProgressFunc reports the fraction of the completion in [0, 1] and an
+// optional message of GDAL.
+//
+// It is called while the dataset is locked, so it must not call the methods
+// of the same dataset (which deadlocks).
+type ProgressFunc func(complete float64, msg string)
+
+var (
+	progressMutex sync.Mutex
+	progressId    uintptr
+	progressMap   = make(map[uintptr]*progressHandle)
+)
+
+// progressHandle binds a context and a ProgressFunc to GDALProgressFunc,
+// GDAL aborts the operation when the context is done.
+type progressHandle struct {
+	id  uintptr
+	ctx context.Context
+	fn  ProgressFunc
+}
+
+func newProgressHandle(ctx context.Context, fn ProgressFunc) *progressHandle {
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+
+	progressId++
+	h := &progressHandle{
+		id:  progressId,
+		ctx: ctx,
+		fn:  fn,
+	}
+	progressMap[h.id] = h
+	return h
+}
+
+func (h *progressHandle) Close() {
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+
+	delete(progressMap, h.id)
+}
+
+// pfnProgress returns the GDALProgressFunc, nil h means no progress.
+func (h *progressHandle) pfnProgress() C.GDALProgressFunc {
+	if h == nil {
+		return nil
+	}
+	return C.GDALProgressFunc(C.cplProgress)
+}
+
+// pProgressData returns the user data of pfnProgress.
+func (h *progressHandle) pProgressData() unsafe.Pointer {
+	if h == nil {
+		return nil
+	}
+	return C.progressArg(C.uintptr_t(h.id))
+}
+
+// err returns the error of the context, nil h means no error.
+func (h *progressHandle) err() error {
+	if h == nil {
+		return nil
+	}
+	return h.ctx.Err()
+}
+
+//export goProgressFunc
+func goProgressFunc(dfComplete C.double, pszMessage *C.char, ctx C.uintptr_t) C.int {
+	progressMutex.Lock()
+	h := progressMap[uintptr(ctx)]
+	progressMutex.Unlock()
+
+	if h == nil {
+		return C.TRUE
+	}
+	if h.ctx.Err() != nil {
+		return C.FALSE
+	}
+	if h.fn != nil {
+		h.fn(float64(dfComplete), C.GoString(pszMessage))
+	}
+	return C.TRUE
+}
+
+// BuildOverviewsContext is same as BuildOverviews, but reports the progress
+// to fn (may be nil) and aborts when ctx is done.
+//
+// fn is called while p is locked, calling the methods of p from fn
+// deadlocks.
+func (p *Dataset) BuildOverviewsContext(ctx context.Context, fn ProgressFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p._Width <= 256 && p._Height <= 256 {
+		return nil
+	}
+
+	h := newProgressHandle(ctx, fn)
+	defer h.Close()
+
+	if err := p.buildOverviews(p.getOverviewList(), h); err != nil {
+		if ctxErr := h.err(); ctxErr != nil {
+			return fmt.Errorf("gdal: Dataset(%q).BuildOverviewsContext failed: %w", p.Filename, ctxErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateDatasetCopyContext is same as CreateDatasetCopy, but reports the
+// progress to fn (may be nil) and aborts when ctx is done.
+//
+// fn is called while src is locked, calling the methods of src from fn
+// deadlocks.
+func CreateDatasetCopyContext(ctx context.Context, filename string, src *Dataset, opt *Options, fn ProgressFunc) (p *Dataset, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	h := newProgressHandle(ctx, fn)
+	defer h.Close()
+
+	if p, err = createDatasetCopy(filename, src, opt, h); err != nil {
+		if ctxErr := h.err(); ctxErr != nil {
+			return nil, fmt.Errorf("gdal: CreateDatasetCopyContext(%q) failed: %w", filename, ctxErr)
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// ReadContext is same as Read, but reports the progress to fn (may be nil)
+// and aborts when ctx is done.
+//
+// With GDAL 1.x, the progress is only reported (and ctx is only checked)
+// between the bands.
+//
+// fn is called while p is locked, calling the methods of p from fn
+// deadlocks.
+func (p *Dataset) ReadContext(ctx context.Context, r image.Rectangle, fn ProgressFunc) (m image.Image, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := newProgressHandle(ctx, fn)
+	defer h.Close()
+
+	pix := make([]byte, r.Dx()*r.Dy()*p._Channels*SizeofKind(p._DataType))
+	if err = p.readWithSize(r, r.Dx(), r.Dy(), pix, 0, p._DataType, h); err != nil {
+		if ctxErr := h.err(); ctxErr != nil {
+			return nil, fmt.Errorf("gdal: Dataset(%q).ReadContext failed: %w", p.Filename, ctxErr)
+		}
+		return nil, err
+	}
+	m = &MemPImage{
+		XMemPMagic: MemPMagic,
+		XRect:      r,
+		XStride:    r.Dx() * p._Channels * SizeofKind(p._DataType),
+		XChannels:  p._Channels,
+		XDataType:  p._DataType,
+		XPix:       pix,
+	}
+	return
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"testing"
+)
+
+func TestCreateDatasetCopyContext(t *testing.T) {
+	tmpname := "z_test_TestCreateDatasetCopyContext.tiff"
+	defer os.Remove(tmpname)
+
+	src, err := OpenDataset("./testdata/lena512color.tiff", GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	var last float64
+	f, err := CreateDatasetCopyContext(context.Background(), tmpname, src, nil, func(complete float64, msg string) {
+		last = complete
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if last != 1 {
+		t.Fatalf("expect = 1, got = %v", last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := f.BuildOverviewsContext(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect = %v, got = %v", context.Canceled, err)
+	}
+	if f.HasOverviews() {
+		t.Fatal("expect no overviews")
+	}
+	if _, err := f.ReadContext(ctx, image.Rect(0, 0, f.Width(), f.Height()), nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect = %v, got = %v", context.Canceled, err)
+	}
+}
+
+func TestDataset_BuildOverviewsContext(t *testing.T) {
+	tmpname := "z_test_TestDataset_BuildOverviewsContext.tiff"
+	defer os.Remove(tmpname)
+
+	src, err := OpenDataset("./testdata/lena512color.tiff", GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	f, err := CreateDatasetCopy(tmpname, src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// cancel at the first progress report
+	err = f.BuildOverviewsContext(ctx, func(complete float64, msg string) {
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect = %v, got = %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// RasterType is the pixel types of Raster.
+type RasterType interface {
+	uint8 | uint16 | int16 | int32 | uint32 | float32 | float64
+}
+
+// Raster is a multi channel raster with the typed pixels, the channels of
+// a pixel are interleaved:
+//
+//	Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*Channels + c]
+type Raster[T RasterType] struct {
+	Rect     image.Rectangle
+	Channels int
+	Stride   int // in elements
+	Pix      []T
+	NoData   *float64
+}
+
+// NewRaster returns a new raster with the given bounds and channels.
+func NewRaster[T RasterType](r image.Rectangle, channels int) *Raster[T] {
+	return &Raster[T]{
+		Rect:     r,
+		Channels: channels,
+		Stride:   r.Dx() * channels,
+		Pix:      make([]T, r.Dx()*r.Dy()*channels),
+	}
+}
+
+func (p *Raster[T]) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// DataType returns the reflect.Kind of T.
+func (p *Raster[T]) DataType() reflect.Kind {
+	return rasterKind[T]()
+}
+
+// PixOffset returns the index of the first channel of the pixel at (x, y).
+func (p *Raster[T]) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*p.Channels
+}
+
+// At returns the c-th channel of the pixel at (x, y), 0 if out of bounds.
+func (p *Raster[T]) At(x, y, c int) T {
+	if !(image.Point{x, y}.In(p.Rect)) || c < 0 || c >= p.Channels {
+		return 0
+	}
+	return p.Pix[p.PixOffset(x, y)+c]
+}
+
+// Set sets the c-th channel of the pixel at (x, y), it is ignored if out of bounds.
+func (p *Raster[T]) Set(x, y, c int, v T) {
+	if !(image.Point{x, y}.In(p.Rect)) || c < 0 || c >= p.Channels {
+		return
+	}
+	p.Pix[p.PixOffset(x, y)+c] = v
+}
+
+// Pixel returns the channels of the pixel at (x, y), nil if out of bounds.
+// The returned slice shares the pixels of p.
+func (p *Raster[T]) Pixel(x, y int) []T {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return nil
+	}
+	i := p.PixOffset(x, y)
+	return p.Pix[i : i+p.Channels : i+p.Channels]
+}
+
+// MemPImage returns the MemPImage sharing the pixels of p.
+func (p *Raster[T]) MemPImage() *MemPImage {
+	size := SizeofKind(p.DataType())
+	return &MemPImage{
+		XMemPMagic: MemPMagic,
+		XRect:      p.Rect,
+		XChannels:  p.Channels,
+		XDataType:  p.DataType(),
+		XPix:       rasterBytes(p.Pix),
+		XStride:    p.Stride * size,
+		XNoData:    p.NoData,
+	}
+}
+
+// RasterFromMemPImage returns the raster of m. The pixels are shared if the
+// data type of m is T, otherwise they are converted to T (truncated and
+// clamped to the range of T).
+func RasterFromMemPImage[T RasterType](m *MemPImage) *Raster[T] {
+	kind := rasterKind[T]()
+	size := SizeofKind(kind)
+
+	if m.XDataType == kind && m.XStride%size == 0 && len(m.XPix) > 0 &&
+		uintptr(unsafe.Pointer(&m.XPix[0]))%uintptr(size) == 0 {
+		return &Raster[T]{
+			Rect:     m.XRect,
+			Channels: m.XChannels,
+			Stride:   m.XStride / size,
+			Pix:      unsafe.Slice((*T)(unsafe.Pointer(&m.XPix[0])), len(m.XPix)/size),
+			NoData:   m.XNoData,
+		}
+	}
+
+	p := NewRaster[T](m.XRect, m.XChannels)
+	p.NoData = m.XNoData
+	n := m.XRect.Dx() * m.XChannels
+	srcSize := SizeofKind(m.XDataType)
+	conv := rasterConv[T]()
+	for y := 0; y < m.XRect.Dy(); y++ {
+		row := m.XPix[y*m.XStride:][:n*srcSize]
+		dst := p.Pix[y*p.Stride:][:n]
+		for i := range dst {
+			dst[i] = conv(PixSlice(row[i*srcSize:][:srcSize]).Value(0, m.XDataType))
+		}
+	}
+	return p
+}
+
+// rasterConv returns the conversion from float64 to T, the value is
+// truncated and clamped to the range of T, NaN is 0 for the integer types.
+func rasterConv[T RasterType]() func(v float64) T {
+	var lo, hi float64
+	switch rasterKind[T]() {
+	case reflect.Uint8:
+		lo, hi = 0, math.MaxUint8
+	case reflect.Uint16:
+		lo, hi = 0, math.MaxUint16
+	case reflect.Int16:
+		lo, hi = math.MinInt16, math.MaxInt16
+	case reflect.Int32:
+		lo, hi = math.MinInt32, math.MaxInt32
+	case reflect.Uint32:
+		lo, hi = 0, math.MaxUint32
+	case reflect.Float32:
+		return func(v float64) T {
+			switch {
+			case v > math.MaxFloat32 && !math.IsInf(v, 1):
+				v = math.MaxFloat32
+			case v < -math.MaxFloat32 && !math.IsInf(v, -1):
+				v = -math.MaxFloat32
+			}
+			return T(v)
+		}
+	default:
+		return func(v float64) T { return T(v) }
+	}
+	return func(v float64) T {
+		switch {
+		case math.IsNaN(v):
+			return 0
+		case v <= lo:
+			return T(lo)
+		case v >= hi:
+			return T(hi)
+		}
+		return T(v)
+	}
+}
+
+// ReadRaster reads the r area of all the bands, GDAL converts the band data
+// type to T.
+func ReadRaster[T RasterType](ds *Dataset, r image.Rectangle) (*Raster[T], error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	p := NewRaster[T](r, ds._Channels)
+	if ds.Opt.NoData != nil {
+		v := *ds.Opt.NoData
+		p.NoData = &v
+	}
+	if err := ds.readWithSize(r, r.Dx(), r.Dy(), rasterBytes(p.Pix), p.Stride*SizeofKind(p.DataType()), p.DataType(), nil); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// WriteRaster writes the r area (intersected with the bounds of m) of m to
+// all the bands, GDAL converts T to the band data type.
+func WriteRaster[T RasterType](ds *Dataset, r image.Rectangle, m *Raster[T]) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if m.Channels != ds._Channels {
+		return fmt.Errorf("gdal: WriteRaster(%q), expect %d channels, got %d.", ds.Filename, ds._Channels, m.Channels)
+	}
+	r = r.Intersect(m.Rect)
+	if r.Empty() {
+		return nil
+	}
+	data := rasterBytes(m.Pix[m.PixOffset(r.Min.X, r.Min.Y):])
+	return ds.write(r, data, m.Stride*SizeofKind(m.DataType()), m.DataType())
+}
+
+func rasterKind[T RasterType]() reflect.Kind {
+	var v T
+	return reflect.TypeOf(v).Kind()
+}
+
+// rasterBytes returns the bytes of pix, the memory is shared.
+func rasterBytes[T RasterType](pix []T) []byte {
+	if len(pix) == 0 {
+		return nil
+	}
+	var v T
+	return unsafe.Slice((*byte)(unsafe.Pointer(&pix[0])), len(pix)*int(unsafe.Sizeof(v)))
+}
@@ -0,0 +1,110 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRaster(t *testing.T) {
+	p := NewRaster[float32](image.Rect(1, 2, 4, 4), 2)
+	if p.DataType() != reflect.Float32 {
+		t.Fatalf("DataType: %v", p.DataType())
+	}
+
+	p.Set(3, 3, 1, 1.5)
+	p.Set(9, 9, 0, 1) // ignored
+	if v := p.At(3, 3, 1); v != 1.5 {
+		t.Fatalf("At: %v", v)
+	}
+	if v := p.At(0, 0, 0); v != 0 {
+		t.Fatalf("At: %v", v)
+	}
+	if pix := p.Pixel(3, 3); len(pix) != 2 || pix[1] != 1.5 {
+		t.Fatalf("Pixel: %v", pix)
+	}
+
+	m := p.MemPImage()
+	if v := m.XPix.Value(m.PixOffset(3, 3)/4+1, reflect.Float32); v != 1.5 {
+		t.Fatalf("MemPImage: %v", v)
+	}
+
+	q := RasterFromMemPImage[float32](m)
+	q.Set(1, 2, 0, 7)
+	if v := p.At(1, 2, 0); v != 7 {
+		t.Fatalf("expect shared pixels, got %v", v)
+	}
+
+	u := RasterFromMemPImage[uint8](m)
+	if v := u.At(3, 3, 1); v != 1 {
+		t.Fatalf("uint8: %v", v)
+	}
+	if v := u.At(1, 2, 0); v != 7 {
+		t.Fatalf("uint8: %v", v)
+	}
+}
+
+func TestRasterFromMemPImage_clamp(t *testing.T) {
+	p := NewRaster[float64](image.Rect(0, 0, 4, 1), 1)
+	copy(p.Pix, []float64{300, -5, 1e40, math.NaN()})
+
+	u := RasterFromMemPImage[uint8](p.MemPImage())
+	if !reflect.DeepEqual(u.Pix, []uint8{255, 0, 255, 0}) {
+		t.Fatalf("uint8: %v", u.Pix)
+	}
+	i := RasterFromMemPImage[int16](p.MemPImage())
+	if !reflect.DeepEqual(i.Pix, []int16{300, -5, math.MaxInt16, 0}) {
+		t.Fatalf("int16: %v", i.Pix)
+	}
+	f := RasterFromMemPImage[float32](p.MemPImage())
+	if f.Pix[2] != math.MaxFloat32 {
+		t.Fatalf("float32: %v", f.Pix)
+	}
+}
+
+func TestReadWriteRaster(t *testing.T) {
+	f, err := CreateDataset("", 4, 3, 2, reflect.Uint16, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	src := NewRaster[float64](image.Rect(0, 0, 4, 3), 2)
+	for i := range src.Pix {
+		src.Pix[i] = float64(i) * 100
+	}
+	if err := WriteRaster(f, src.Rect, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteRaster(f, src.Rect, NewRaster[float64](src.Rect, 3)); err == nil {
+		t.Fatal("expect channels error")
+	}
+
+	got, err := ReadRaster[int32](f, image.Rect(1, 1, 3, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 1; y < 3; y++ {
+		for x := 1; x < 3; x++ {
+			for c := 0; c < 2; c++ {
+				if v, expect := got.At(x, y, c), int32(src.At(x, y, c)); v != expect {
+					t.Fatalf("(%d, %d, %d): expect = %v, got = %v", x, y, c, expect, v)
+				}
+			}
+		}
+	}
+
+	m, err := f.Read(image.Rect(0, 0, 4, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u16 := RasterFromMemPImage[uint16](m.(*MemPImage))
+	if v := u16.At(3, 2, 1); v != uint16(src.At(3, 2, 1)) {
+		t.Fatalf("Read: %v", v)
+	}
+}
@@ -6,6 +6,7 @@ package gdal
 
 import (
 	"image"
+	"io"
 	"reflect"
 )
 
@@ -18,9 +19,7 @@ func LoadConfig(filename string) (config image.Config, err error) {
 	}
 	defer f.Close()
 
-	config.ColorModel = ColorModel(f._Channels, f._DataType)
-	config.Width, config.Height = f._Width, f._Height
-	return
+	return readConfig(f), nil
 }
 
 // Load reads a GDAL image from file and returns it as an image.Image.
@@ -31,11 +30,83 @@ func Load(filename string, buffer ...[]byte) (m image.Image, err error) {
 	}
 	defer f.Close()
 
-	p := NewMemPImage(image.Rect(0, 0, f._Width, f._Height), f._Channels, f._DataType)
-	if err = f.ReadToBuf(p.XRect, p.XPix, p.XStride); err != nil {
+	return readImage(f)
+}
+
+// LoadImage reads a GDAL image from file and returns it as an Image.
+//
+// The NoData value of the first band is kept in m.XNoData.
+func LoadImage(filename string, buffer ...[]byte) (m *MemPImage, err error) {
+	f, err := OpenDataset(filename, GA_ReadOnly)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return readMemPImage(f)
+}
+
+// DecodeConfig returns the color model and dimensions of a GDAL image read
+// from r, the whole data of r is read.
+func DecodeConfig(r io.Reader) (config image.Config, err error) {
+	f, err := OpenDatasetFromReader(r)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return readConfig(f), nil
+}
+
+// Decode reads a GDAL image from r, the result is same as Load.
+func Decode(r io.Reader) (m image.Image, err error) {
+	f, err := OpenDatasetFromReader(r)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return readImage(f)
+}
+
+func readConfig(f *Dataset) (config image.Config) {
+	config.ColorModel = ColorModel(f._Channels, f._DataType)
+	if pal := f.palette(); pal != nil {
+		config.ColorModel = pal
+	}
+	config.Width, config.Height = f._Width, f._Height
+	return
+}
+
+// readImage reads the whole dataset, the std image types are used if possible.
+//
+// The 8-bit paletted dataset is returned as *image.Paletted, the channels
+// are mapped by the color interpretations of the bands (see
+// MemPImage.StdImageWithColorInterp).
+func readImage(f *Dataset) (m image.Image, err error) {
+	p, err := readMemPImage(f)
+	if err != nil {
 		return
 	}
 
+	if pal := f.palette(); pal != nil {
+		var maxIndex uint8
+		for _, v := range p.XPix {
+			if v > maxIndex {
+				maxIndex = v
+			}
+		}
+		return &image.Paletted{
+			Pix:     p.XPix,
+			Stride:  p.XStride,
+			Rect:    p.XRect,
+			Palette: padPalette(pal, int(maxIndex)+1),
+		}, nil
+	}
+	if m, ok := p.mapColorInterp(f.ColorInterpretations()); ok {
+		return m, nil
+	}
+
 	if p.XChannels == 1 && p.XDataType == reflect.Uint8 {
 		return &image.Gray{
 			Pix:    p.XPix,
@@ -75,17 +146,11 @@ func Load(filename string, buffer ...[]byte) (m image.Image, err error) {
 	return
 }
 
-// LoadImage reads a GDAL image from file and returns it as an Image.
-func LoadImage(filename string, buffer ...[]byte) (m *MemPImage, err error) {
-	f, err := OpenDataset(filename, GA_ReadOnly)
-	if err != nil {
-		return
-	}
-	defer f.Close()
-
+func readMemPImage(f *Dataset) (m *MemPImage, err error) {
 	m = NewMemPImage(image.Rect(0, 0, f._Width, f._Height), f._Channels, f._DataType)
 	if err = f.ReadToBuf(m.XRect, m.XPix, m.XStride); err != nil {
-		return
+		return nil, err
 	}
+	m.XNoData = f.Opt.NoData
 	return
 }
@@ -0,0 +1,376 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+/*
+#include <gdal.h>
+#include <gdal_version.h>
+#include <ogr_srs_api.h>
+#include <cpl_conv.h>
+#include <stdlib.h>
+
+static OGRSpatialReferenceH newSpatialReference() {
+	OGRSpatialReferenceH hSRS = OSRNewSpatialReference(NULL);
+#if GDAL_VERSION_MAJOR >= 3
+	// keep the x/y (lon/lat) axis order of GDAL 2
+	if(hSRS != NULL) {
+		OSRSetAxisMappingStrategy(hSRS, OAMS_TRADITIONAL_GIS_ORDER);
+	}
+#endif
+	return hSRS;
+}
+
+static OGRErr exportToPROJJSON(OGRSpatialReferenceH hSRS, char **ppszReturn) {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(3,1,0)
+	return OSRExportToPROJJSON(hSRS, ppszReturn, NULL);
+#else
+	*ppszReturn = NULL;
+	return OGRERR_UNSUPPORTED_OPERATION;
+#endif
+}
+*/
+import "C"
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// SpatialReference is a coordinate system (OGRSpatialReference).
+type SpatialReference struct {
+	mu   sync.Mutex
+	hSRS C.OGRSpatialReferenceH
+}
+
+// NewSpatialReference creates a SpatialReference from WKT, PROJ string,
+// "EPSG:n" or any other definition accepted by OSRSetFromUserInput.
+// If definition is empty, an empty SpatialReference is returned.
+func NewSpatialReference(definition string) (p *SpatialReference, err error) {
+	p = &SpatialReference{
+		hSRS: C.newSpatialReference(),
+	}
+	if p.hSRS == nil {
+		return nil, fmt.Errorf("gdal: NewSpatialReference(%q) failed.", definition)
+	}
+	if definition == "" {
+		return p, nil
+	}
+
+	cDefinition := C.CString(definition)
+	defer C.free(unsafe.Pointer(cDefinition))
+
+	var eErr C.OGRErr
+	cplErr := cplCapture(func() {
+		eErr = C.OSRSetFromUserInput(p.hSRS, cDefinition)
+	})
+	if eErr != C.OGRERR_NONE {
+		p.Close()
+		return nil, cplErrorf(cplErr, "gdal: NewSpatialReference(%q) failed", definition)
+	}
+	return p, nil
+}
+
+// NewSpatialReferenceFromEPSG creates a SpatialReference from the EPSG code.
+func NewSpatialReferenceFromEPSG(code int) (p *SpatialReference, err error) {
+	if p, err = NewSpatialReference(""); err != nil {
+		return nil, err
+	}
+	if err = p.ImportFromEPSG(code); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *SpatialReference) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.hSRS != nil {
+		C.OSRRelease(p.hSRS)
+		p.hSRS = nil
+	}
+	return nil
+}
+
+func (p *SpatialReference) Clone() (*SpatialReference, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q := &SpatialReference{
+		hSRS: C.OSRClone(p.hSRS),
+	}
+	if q.hSRS == nil {
+		return nil, fmt.Errorf("gdal: SpatialReference.Clone failed.")
+	}
+	return q, nil
+}
+
+func (p *SpatialReference) ImportFromEPSG(code int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var eErr C.OGRErr
+	cplErr := cplCapture(func() {
+		eErr = C.OSRImportFromEPSG(p.hSRS, C.int(code))
+	})
+	if eErr != C.OGRERR_NONE {
+		return cplErrorf(cplErr, "gdal: SpatialReference.ImportFromEPSG(%d) failed", code)
+	}
+	return nil
+}
+
+func (p *SpatialReference) ImportFromProj4(proj4 string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cProj4 := C.CString(proj4)
+	defer C.free(unsafe.Pointer(cProj4))
+
+	var eErr C.OGRErr
+	cplErr := cplCapture(func() {
+		eErr = C.OSRImportFromProj4(p.hSRS, cProj4)
+	})
+	if eErr != C.OGRERR_NONE {
+		return cplErrorf(cplErr, "gdal: SpatialReference.ImportFromProj4(%q) failed", proj4)
+	}
+	return nil
+}
+
+func (p *SpatialReference) ImportFromWkt(wkt string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cWkt := C.CString(wkt)
+	defer C.free(unsafe.Pointer(cWkt))
+
+	// OSRImportFromWkt moves the pointer, use a copy
+	pszWkt := cWkt
+
+	var eErr C.OGRErr
+	cplErr := cplCapture(func() {
+		eErr = C.OSRImportFromWkt(p.hSRS, &pszWkt)
+	})
+	if eErr != C.OGRERR_NONE {
+		return cplErrorf(cplErr, "gdal: SpatialReference.ImportFromWkt(%q) failed", wkt)
+	}
+	return nil
+}
+
+func (p *SpatialReference) ExportToWkt() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.export("ExportToWkt", func(ppsz **C.char) C.OGRErr {
+		return C.OSRExportToWkt(p.hSRS, ppsz)
+	})
+}
+
+func (p *SpatialReference) ExportToPrettyWkt() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.export("ExportToPrettyWkt", func(ppsz **C.char) C.OGRErr {
+		return C.OSRExportToPrettyWkt(p.hSRS, ppsz, C.FALSE)
+	})
+}
+
+func (p *SpatialReference) ExportToProj4() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.export("ExportToProj4", func(ppsz **C.char) C.OGRErr {
+		return C.OSRExportToProj4(p.hSRS, ppsz)
+	})
+}
+
+// ExportToPROJJSON exports the SpatialReference as PROJJSON (GDAL >= 3.1).
+func (p *SpatialReference) ExportToPROJJSON() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.export("ExportToPROJJSON", func(ppsz **C.char) C.OGRErr {
+		return C.exportToPROJJSON(p.hSRS, ppsz)
+	})
+}
+
+func (p *SpatialReference) export(name string, fn func(ppsz **C.char) C.OGRErr) (string, error) {
+	var (
+		pszResult *C.char
+		eErr      C.OGRErr
+	)
+	cplErr := cplCapture(func() {
+		eErr = fn(&pszResult)
+	})
+	defer C.CPLFree(unsafe.Pointer(pszResult))
+
+	if eErr != C.OGRERR_NONE {
+		return "", cplErrorf(cplErr, "gdal: SpatialReference.%s failed", name)
+	}
+	return C.GoString(pszResult), nil
+}
+
+// IsSame reports whether p and q describe the same coordinate system.
+func (p *SpatialReference) IsSame(q *SpatialReference) bool {
+	if p == q {
+		return true
+	}
+	if q == nil {
+		return false
+	}
+	defer lockSpatialReferences(p, q)()
+
+	return C.OSRIsSame(p.hSRS, q.hSRS) != 0
+}
+
+// lockSpatialReferences locks p and q in address order (to avoid deadlock
+// with the reversed call), and returns the unlock function.
+func lockSpatialReferences(p, q *SpatialReference) (unlock func()) {
+	if p == q {
+		p.mu.Lock()
+		return p.mu.Unlock
+	}
+	if uintptr(unsafe.Pointer(q)) < uintptr(unsafe.Pointer(p)) {
+		p, q = q, p
+	}
+	p.mu.Lock()
+	q.mu.Lock()
+	return func() {
+		q.mu.Unlock()
+		p.mu.Unlock()
+	}
+}
+
+func (p *SpatialReference) IsGeographic() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return C.OSRIsGeographic(p.hSRS) != 0
+}
+
+func (p *SpatialReference) IsProjected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return C.OSRIsProjected(p.hSRS) != 0
+}
+
+// AuthorityName returns the authority name (such as "EPSG") of the target
+// node, target is "" for the root node.
+func (p *SpatialReference) AuthorityName(target string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var cTarget *C.char
+	if target != "" {
+		cTarget = C.CString(target)
+		defer C.free(unsafe.Pointer(cTarget))
+	}
+	return C.GoString(C.OSRGetAuthorityName(p.hSRS, cTarget))
+}
+
+// AuthorityCode returns the authority code (such as "4326") of the target
+// node, target is "" for the root node.
+func (p *SpatialReference) AuthorityCode(target string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var cTarget *C.char
+	if target != "" {
+		cTarget = C.CString(target)
+		defer C.free(unsafe.Pointer(cTarget))
+	}
+	return C.GoString(C.OSRGetAuthorityCode(p.hSRS, cTarget))
+}
+
+// CoordinateTransformation transforms points between two SpatialReference.
+type CoordinateTransformation struct {
+	mu  sync.Mutex
+	hCT C.OGRCoordinateTransformationH
+}
+
+func NewCoordinateTransformation(src, dst *SpatialReference) (p *CoordinateTransformation, err error) {
+	if src == nil || dst == nil {
+		return nil, fmt.Errorf("gdal: NewCoordinateTransformation, nil SpatialReference.")
+	}
+	defer lockSpatialReferences(src, dst)()
+
+	p = new(CoordinateTransformation)
+	cplErr := cplCapture(func() {
+		p.hCT = C.OCTNewCoordinateTransformation(src.hSRS, dst.hSRS)
+	})
+	if p.hCT == nil {
+		return nil, cplErrorf(cplErr, "gdal: NewCoordinateTransformation failed")
+	}
+	return p, nil
+}
+
+func (p *CoordinateTransformation) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.hCT != nil {
+		C.OCTDestroyCoordinateTransformation(p.hCT)
+		p.hCT = nil
+	}
+	return nil
+}
+
+// Transform transforms the points in place, z may be nil.
+func (p *CoordinateTransformation) Transform(x, y, z []float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(x) != len(y) || (z != nil && len(z) != len(x)) {
+		return fmt.Errorf("gdal: CoordinateTransformation.Transform, length mismatch: %d, %d, %d", len(x), len(y), len(z))
+	}
+	if len(x) == 0 {
+		return nil
+	}
+
+	var pz *C.double
+	if z != nil {
+		pz = (*C.double)(unsafe.Pointer(&z[0]))
+	}
+
+	var bOK C.int
+	cplErr := cplCapture(func() {
+		bOK = C.OCTTransform(p.hCT, C.int(len(x)),
+			(*C.double)(unsafe.Pointer(&x[0])),
+			(*C.double)(unsafe.Pointer(&y[0])),
+			pz,
+		)
+	})
+	if bOK == 0 {
+		return cplErrorf(cplErr, "gdal: CoordinateTransformation.Transform failed")
+	}
+	return nil
+}
+
+// TransformPoint transforms a single point.
+func (p *CoordinateTransformation) TransformPoint(x, y float64) (float64, float64, error) {
+	xs, ys := []float64{x}, []float64{y}
+	if err := p.Transform(xs, ys, nil); err != nil {
+		return x, y, err
+	}
+	return xs[0], ys[0], nil
+}
+
+// SpatialReference returns the SpatialReference of the dataset.
+func (p *Dataset) SpatialReference() (*SpatialReference, error) {
+	p.mu.Lock()
+	projection := p.Opt.Projection
+	p.mu.Unlock()
+
+	if projection == "" {
+		return nil, fmt.Errorf("gdal: Dataset(%q).SpatialReference, no projection.", p.Filename)
+	}
+	return NewSpatialReference(projection)
+}
+
+// SetSpatialReference is same as SetProjection(srs).
+func (p *Dataset) SetSpatialReference(srs *SpatialReference) error {
+	return p.SetProjection(srs)
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSpatialReference(t *testing.T) {
+	wgs84, err := NewSpatialReferenceFromEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wgs84.Close()
+
+	if s := wgs84.AuthorityCode(""); s != "4326" {
+		t.Fatalf("AuthorityCode: %q", s)
+	}
+
+	wkt, err := wgs84.ExportToWkt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srs, err := NewSpatialReference(wkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srs.Close()
+
+	if !srs.IsSame(wgs84) {
+		t.Fatal("expect same srs")
+	}
+	if srs.IsSame(nil) {
+		t.Fatal("expect not same with nil")
+	}
+
+	// a.IsSame(b) and b.IsSame(a) must not deadlock
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(a, b *SpatialReference) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a.IsSame(b)
+			}
+		}([]*SpatialReference{srs, wgs84}[i], []*SpatialReference{wgs84, srs}[i])
+	}
+	wg.Wait()
+
+	merc, err := NewSpatialReference("EPSG:3857")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer merc.Close()
+
+	ct, err := NewCoordinateTransformation(wgs84, merc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ct.Close()
+
+	x, y, err := ct.TransformPoint(180, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x-20037508.34) > 0.01 || math.Abs(y) > 0.01 {
+		t.Fatalf("TransformPoint: got = (%v, %v)", x, y)
+	}
+}
+
+func TestDataset_SetProjection(t *testing.T) {
+	f, err := CreateDataset("", 1, 1, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	wgs84, err := NewSpatialReferenceFromEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wgs84.Close()
+
+	if err := f.SetProjection(wgs84); err != nil {
+		t.Fatal(err)
+	}
+	srs, err := f.SpatialReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srs.Close()
+	if !srs.IsSame(wgs84) {
+		t.Fatal("expect same srs")
+	}
+
+	if err := f.SetProjection(4326); err == nil {
+		t.Fatal("expect error for unsupported type")
+	}
+}
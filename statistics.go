@@ -0,0 +1,258 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+/*
+#include <gdal.h>
+#include <gdal_version.h>
+#include <cpl_conv.h>
+
+// getRasterHistogram is GDALGetRasterHistogramEx (GDAL >= 2.0).
+static CPLErr getRasterHistogram(
+	GDALRasterBandH hBand, double dfMin, double dfMax,
+	int nBuckets, GUIntBig *panHistogram,
+	int bIncludeOutOfRange, int bApproxOK
+) {
+#if GDAL_VERSION_MAJOR >= 2
+	return GDALGetRasterHistogramEx(hBand, dfMin, dfMax,
+		nBuckets, panHistogram,
+		bIncludeOutOfRange, bApproxOK, NULL, NULL
+	);
+#else
+	CPLErr eErr;
+	int i;
+	int *panCounts = (int*)VSIMalloc2(nBuckets, sizeof(int));
+	if(panCounts == NULL) {
+		return CE_Failure;
+	}
+	eErr = GDALGetRasterHistogram(hBand, dfMin, dfMax,
+		nBuckets, panCounts,
+		bIncludeOutOfRange, bApproxOK, NULL, NULL
+	);
+	for(i = 0; i < nBuckets; i++) {
+		panHistogram[i] = (GUIntBig)(unsigned int)panCounts[i];
+	}
+	VSIFree(panCounts);
+	return eErr;
+#endif
+}
+
+// getDefaultHistogram is GDALGetDefaultHistogramEx (GDAL >= 2.0).
+// The *ppanHistogram must be freed with VSIFree.
+static CPLErr getDefaultHistogram(
+	GDALRasterBandH hBand, double *pdfMin, double *pdfMax,
+	int *pnBuckets, GUIntBig **ppanHistogram, int bForce
+) {
+#if GDAL_VERSION_MAJOR >= 2
+	return GDALGetDefaultHistogramEx(hBand, pdfMin, pdfMax,
+		pnBuckets, ppanHistogram, bForce, NULL, NULL
+	);
+#else
+	CPLErr eErr;
+	int i;
+	int *panCounts = NULL;
+	*ppanHistogram = NULL;
+	eErr = GDALGetDefaultHistogram(hBand, pdfMin, pdfMax,
+		pnBuckets, &panCounts, bForce, NULL, NULL
+	);
+	if(eErr == CE_None && *pnBuckets > 0) {
+		*ppanHistogram = (GUIntBig*)VSIMalloc2(*pnBuckets, sizeof(GUIntBig));
+		if(*ppanHistogram == NULL) {
+			eErr = CE_Failure;
+		} else {
+			for(i = 0; i < *pnBuckets; i++) {
+				(*ppanHistogram)[i] = (GUIntBig)(unsigned int)panCounts[i];
+			}
+		}
+	}
+	VSIFree(panCounts);
+	return eErr;
+#endif
+}
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ErrNoStatistics is returned by RasterBand.Statistics if force is false
+// and the band has no cached statistics.
+var ErrNoStatistics = errors.New("gdal: no statistics")
+
+type Statistics struct {
+	Min    float64
+	Max    float64
+	Mean   float64
+	StdDev float64
+}
+
+type Histogram struct {
+	Min     float64
+	Max     float64
+	Buckets []uint64
+}
+
+// HistogramOptions are the options of RasterBand.Histogram.
+//
+// If Buckets is zero, the default histogram of the band is returned.
+type HistogramOptions struct {
+	Min               float64
+	Max               float64
+	Buckets           int
+	IncludeOutOfRange bool
+	ApproxOK          bool
+}
+
+// Statistics returns the statistics of the band.
+//
+// If approxOK is true, the statistics may be computed from overviews or a
+// subset of the blocks. If force is false, only the cached statistics are
+// returned, or an error wrapping ErrNoStatistics if there are none. GDAL
+// caches the computed statistics as metadata of the band.
+func (p *RasterBand) Statistics(approxOK, force bool) (st Statistics, err error) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALGetRasterStatistics(p.poBand,
+			cBool(approxOK), cBool(force),
+			(*C.double)(&st.Min), (*C.double)(&st.Max),
+			(*C.double)(&st.Mean), (*C.double)(&st.StdDev),
+		)
+	})
+	if !force && cErr == C.CE_Warning {
+		return st, fmt.Errorf("gdal: RasterBand(%q, %d).Statistics: %w", p.ds.Filename, p.index, ErrNoStatistics)
+	}
+	if cErr != C.CE_None {
+		return st, cplErrorf(cplErr, "gdal: RasterBand(%q, %d).Statistics failed", p.ds.Filename, p.index)
+	}
+	return st, nil
+}
+
+// ComputeStatistics scans the band and computes the statistics.
+//
+// If the dataset is opened with GA_Update, the statistics are saved as the
+// metadata of the band (in the file or the .aux.xml side car file).
+func (p *RasterBand) ComputeStatistics(approxOK bool) (st Statistics, err error) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.GDALComputeRasterStatistics(p.poBand, cBool(approxOK),
+			(*C.double)(&st.Min), (*C.double)(&st.Max),
+			(*C.double)(&st.Mean), (*C.double)(&st.StdDev),
+			nil, nil,
+		)
+	})
+	if cErr != C.CE_None {
+		return st, cplErrorf(cplErr, "gdal: RasterBand(%q, %d).ComputeStatistics failed", p.ds.Filename, p.index)
+	}
+
+	if p.ds.access == GA_Update {
+		// persist the statistics as the metadata of the band
+		cplErr = cplCapture(func() {
+			cErr = C.GDALSetRasterStatistics(p.poBand,
+				C.double(st.Min), C.double(st.Max),
+				C.double(st.Mean), C.double(st.StdDev),
+			)
+			if cErr == C.CE_None {
+				C.GDALFlushCache(p.ds.poDataset)
+			}
+		})
+		if cErr != C.CE_None {
+			return st, cplErrorf(cplErr, "gdal: RasterBand(%q, %d).ComputeStatistics, save statistics failed", p.ds.Filename, p.index)
+		}
+	}
+	return st, nil
+}
+
+// Histogram computes the histogram of the band.
+//
+// If opt is nil or opt.Buckets is zero, the default histogram is returned.
+func (p *RasterBand) Histogram(opt *HistogramOptions) (h *Histogram, err error) {
+	p.ds.mu.Lock()
+	defer p.ds.mu.Unlock()
+
+	if opt == nil || opt.Buckets == 0 {
+		return p.defaultHistogram()
+	}
+	if opt.Buckets < 0 {
+		return nil, fmt.Errorf("gdal: RasterBand(%q, %d).Histogram, bad buckets: %d", p.ds.Filename, p.index, opt.Buckets)
+	}
+
+	h = &Histogram{
+		Min:     opt.Min,
+		Max:     opt.Max,
+		Buckets: make([]uint64, opt.Buckets),
+	}
+
+	var cErr C.CPLErr
+	cplErr := cplCapture(func() {
+		cErr = C.getRasterHistogram(p.poBand,
+			C.double(opt.Min), C.double(opt.Max),
+			C.int(opt.Buckets), (*C.GUIntBig)(unsafe.Pointer(&h.Buckets[0])),
+			cBool(opt.IncludeOutOfRange), cBool(opt.ApproxOK),
+		)
+	})
+	if cErr != C.CE_None {
+		return nil, cplErrorf(cplErr, "gdal: RasterBand(%q, %d).Histogram failed", p.ds.Filename, p.index)
+	}
+	return h, nil
+}
+
+func (p *RasterBand) defaultHistogram() (h *Histogram, err error) {
+	var (
+		dfMin, dfMax C.double
+		nBuckets     C.int
+		panHistogram *C.GUIntBig
+		cErr         C.CPLErr
+	)
+	cplErr := cplCapture(func() {
+		cErr = C.getDefaultHistogram(p.poBand,
+			&dfMin, &dfMax, &nBuckets, &panHistogram,
+			C.TRUE,
+		)
+	})
+	if cErr != C.CE_None {
+		return nil, cplErrorf(cplErr, "gdal: RasterBand(%q, %d).Histogram failed", p.ds.Filename, p.index)
+	}
+	defer C.VSIFree(unsafe.Pointer(panHistogram))
+
+	h = &Histogram{
+		Min:     float64(dfMin),
+		Max:     float64(dfMax),
+		Buckets: make([]uint64, int(nBuckets)),
+	}
+	if n := int(nBuckets); n > 0 {
+		copy(h.Buckets, (*[1 << 30]uint64)(unsafe.Pointer(panHistogram))[:n:n])
+	}
+	return h, nil
+}
+
+// Statistics returns the statistics of all bands, see RasterBand.Statistics.
+func (p *Dataset) Statistics(approxOK, force bool) ([]Statistics, error) {
+	stats := make([]Statistics, p._Channels)
+	for i := 0; i < p._Channels; i++ {
+		band, err := p.Band(i)
+		if err != nil {
+			return nil, err
+		}
+		if stats[i], err = band.Statistics(approxOK, force); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+func cBool(v bool) C.int {
+	if v {
+		return C.TRUE
+	}
+	return C.FALSE
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Subdataset is an item of the SUBDATASETS metadata domain.
+//
+// Name can be passed to OpenDataset directly, such as:
+//
+//	NETCDF:"sst.nc":tos
+//	HDF5:"data.h5"://group/var
+//	GPKG:tiles.gpkg:table
+type Subdataset struct {
+	Name        string
+	Description string
+}
+
+// Subdatasets returns the subdatasets of a container dataset (such as
+// NetCDF, HDF5 and GPKG), in the order reported by the driver.
+func (p *Dataset) Subdatasets() []Subdataset {
+	md := p.GetMetadata(MetadataDomain_Subdatasets)
+
+	// SUBDATASET_1_NAME=..., SUBDATASET_1_DESC=...
+	items := make(map[int]*Subdataset)
+	for k, v := range md {
+		if !strings.HasPrefix(k, "SUBDATASET_") {
+			continue
+		}
+		ss := strings.SplitN(strings.TrimPrefix(k, "SUBDATASET_"), "_", 2)
+		if len(ss) != 2 {
+			continue
+		}
+		idx, err := strconv.Atoi(ss[0])
+		if err != nil {
+			continue
+		}
+		if items[idx] == nil {
+			items[idx] = new(Subdataset)
+		}
+		switch ss[1] {
+		case "NAME":
+			items[idx].Name = v
+		case "DESC":
+			items[idx].Description = v
+		}
+	}
+
+	idxList := make([]int, 0, len(items))
+	for idx, item := range items {
+		if item.Name != "" {
+			idxList = append(idxList, idx)
+		}
+	}
+	sort.Ints(idxList)
+
+	subdatasets := make([]Subdataset, len(idxList))
+	for i, idx := range idxList {
+		subdatasets[i] = *items[idx]
+	}
+	return subdatasets
+}
+
+// OpenSubdataset opens the i-th subdataset, i is in [0, len(Subdatasets())).
+func (p *Dataset) OpenSubdataset(i int, flag Access) (*Dataset, error) {
+	subdatasets := p.Subdatasets()
+	if i < 0 || i >= len(subdatasets) {
+		return nil, fmt.Errorf("gdal: Dataset(%q).OpenSubdataset(%d), index out of range.", p.Filename, i)
+	}
+	return OpenDataset(subdatasets[i].Name, flag)
+}
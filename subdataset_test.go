@@ -0,0 +1,52 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDataset_Subdatasets(t *testing.T) {
+	f, err := CreateDataset("", 1, 1, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := f.SetMetadata(MetadataDomain_Subdatasets, map[string]string{
+		"SUBDATASET_10_NAME": "./testdata/video-001-gray.tiff",
+		"SUBDATASET_10_DESC": "gray",
+		"SUBDATASET_2_NAME":  "./testdata/video-001.tiff",
+		"SUBDATASET_2_DESC":  "rgb",
+		"SUBDATASET_3_DESC":  "no name",
+		"SUBDATASET_X_NAME":  "bad index",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []Subdataset{
+		{Name: "./testdata/video-001.tiff", Description: "rgb"},
+		{Name: "./testdata/video-001-gray.tiff", Description: "gray"},
+	}
+	if got := f.Subdatasets(); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect = %v, got = %v", expect, got)
+	}
+
+	sub, err := f.OpenSubdataset(1, GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	if sub.Channels() != 1 {
+		t.Fatalf("channels: expect = 1, got = %d", sub.Channels())
+	}
+
+	for _, i := range []int{-1, len(expect)} {
+		if _, err := f.OpenSubdataset(i, GA_ReadOnly); err == nil {
+			t.Fatalf("OpenSubdataset(%d): expect error", i)
+		}
+	}
+}
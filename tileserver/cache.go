@@ -0,0 +1,60 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tileserver
+
+import (
+	"container/list"
+	"sync"
+)
+
+// tileCache is a LRU cache of the encoded tiles.
+type tileCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type tileCacheEntry struct {
+	key  string
+	data []byte
+}
+
+func newTileCache(size int) *tileCache {
+	return &tileCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (p *tileCache) Get(key string) (data []byte, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := p.items[key]; ok {
+		p.ll.MoveToFront(e)
+		return e.Value.(*tileCacheEntry).data, true
+	}
+	return nil, false
+}
+
+func (p *tileCache) Set(key string, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := p.items[key]; ok {
+		p.ll.MoveToFront(e)
+		e.Value.(*tileCacheEntry).data = data
+		return
+	}
+	p.items[key] = p.ll.PushFront(&tileCacheEntry{key: key, data: data})
+
+	for p.ll.Len() > p.size {
+		e := p.ll.Back()
+		p.ll.Remove(e)
+		delete(p.items, e.Value.(*tileCacheEntry).key)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tileserver
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/chai2010/gdal"
+)
+
+// mbtilesWriter writes the tiles of the max zoom level to a MBTiles file by
+// the MBTiles driver of GDAL (GDAL >= 2.1), and then builds the lower zoom
+// levels as the overviews.
+type mbtilesWriter struct {
+	ds       *gdal.Dataset
+	tiles    image.Rectangle // the tile range of the max zoom level
+	tileSize int
+	minZoom  int
+	maxZoom  int
+}
+
+func newMBTilesWriter(filename string, s *Server, minZoom, maxZoom int, opt *PyramidOptions) (p *mbtilesWriter, err error) {
+	merc, err := gdal.NewSpatialReferenceFromEPSG(3857)
+	if err != nil {
+		return nil, err
+	}
+	defer merc.Close()
+
+	wkt, err := merc.ExportToWkt()
+	if err != nil {
+		return nil, err
+	}
+
+	p = &mbtilesWriter{
+		tiles:    s.TileRange(maxZoom),
+		tileSize: opt.TileSize,
+		minZoom:  minZoom,
+		maxZoom:  maxZoom,
+	}
+	b := TileBounds(maxZoom, p.tiles.Min.X, p.tiles.Min.Y)
+	res := Resolution(maxZoom, p.tileSize)
+
+	extOptions := map[string]string{
+		"NAME": strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)),
+		"TYPE": "baselayer",
+	}
+	if opt.Format == "jpg" {
+		extOptions["TILE_FORMAT"] = "JPEG"
+		extOptions["QUALITY"] = strconv.Itoa(opt.JPEGQuality)
+	} else {
+		extOptions["TILE_FORMAT"] = "PNG"
+	}
+	if p.tileSize != 256 {
+		extOptions["BLOCKSIZE"] = strconv.Itoa(p.tileSize)
+	}
+
+	os.Remove(filename)
+	p.ds, err = gdal.CreateDataset(filename,
+		p.tiles.Dx()*p.tileSize, p.tiles.Dy()*p.tileSize, 4, reflect.Uint8,
+		&gdal.Options{
+			DriverName: "MBTiles",
+			Projection: wkt,
+			Transform:  [6]float64{b.MinX, res, 0, b.MaxY, 0, -res},
+			ExtOptions: extOptions,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if opt.ResampleType != gdal.ResampleType_Nil {
+		if err = p.ds.SetResampleType(opt.ResampleType); err != nil {
+			p.ds.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// WriteTile writes the XYZ tile of the max zoom level.
+func (p *mbtilesWriter) WriteTile(z, x, y int, m *image.NRGBA) error {
+	ts := p.tileSize
+	x, y = (x-p.tiles.Min.X)*ts, (y-p.tiles.Min.Y)*ts
+	return p.ds.WriteFromBuf(image.Rect(x, y, x+ts, y+ts), m.Pix, m.Stride)
+}
+
+// Close builds the zoom levels in [minZoom, maxZoom) and closes the file.
+func (p *mbtilesWriter) Close() error {
+	var overviewList []int
+	for z := p.maxZoom - 1; z >= p.minZoom; z-- {
+		overviewList = append(overviewList, 1<<uint(p.maxZoom-z))
+	}
+	var err error
+	if len(overviewList) > 0 {
+		err = p.ds.BuildOverviewsWithList(overviewList)
+	}
+	if closeErr := p.ds.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
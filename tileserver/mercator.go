@@ -0,0 +1,57 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tileserver
+
+import (
+	"math"
+
+	"github.com/chai2010/gdal"
+)
+
+// MaxZoom is the max zoom level of the tiles.
+const MaxZoom = 30
+
+// OriginShift is the half size of the Web Mercator (EPSG:3857) world in meters.
+const OriginShift = math.Pi * 6378137
+
+// TileBounds returns the bounds of the XYZ tile in Web Mercator (EPSG:3857),
+// the y origin of XYZ tiles is at the top.
+func TileBounds(z, x, y int) gdal.GeoRect {
+	size := 2 * OriginShift / float64(uint64(1)<<uint(z))
+	return gdal.GeoRect{
+		MinX: -OriginShift + float64(x)*size,
+		MinY: OriginShift - float64(y+1)*size,
+		MaxX: -OriginShift + float64(x+1)*size,
+		MaxY: OriginShift - float64(y)*size,
+	}
+}
+
+// Resolution returns the meters per pixel of the zoom level.
+func Resolution(z, tileSize int) float64 {
+	return 2 * OriginShift / float64(tileSize) / float64(uint64(1)<<uint(z))
+}
+
+// FlipY converts y between XYZ and TMS tiles.
+func FlipY(z, y int) int {
+	return (1 << uint(z)) - 1 - y
+}
+
+// MercatorToLonLat converts the Web Mercator coordinates to WGS84 degrees.
+func MercatorToLonLat(x, y float64) (lon, lat float64) {
+	lon = x / OriginShift * 180
+	lat = math.Atan(math.Sinh(y/OriginShift*math.Pi)) * 180 / math.Pi
+	return
+}
+
+// zoomForResolution returns the min zoom level whose resolution is not
+// coarser than res.
+func zoomForResolution(res float64, tileSize int) int {
+	for z := 0; z < MaxZoom; z++ {
+		if Resolution(z, tileSize) <= res*(1+1e-6) {
+			return z
+		}
+	}
+	return MaxZoom
+}
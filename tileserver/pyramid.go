@@ -0,0 +1,346 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tileserver
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chai2010/gdal"
+)
+
+type Tiling int
+
+const (
+	Tiling_Mercator Tiling = iota // "MERCATOR", Web Mercator XYZ tiles
+	Tiling_Raster                 // "RASTER", raster pixel tiles (like gdal.Dataset.ReadOverview)
+)
+
+func NewTiling(name string) Tiling {
+	switch strings.ToUpper(name) {
+	case "MERCATOR":
+		return Tiling_Mercator
+	case "RASTER":
+		return Tiling_Raster
+	}
+	return Tiling_Mercator
+}
+
+func (p Tiling) Name() string {
+	switch p {
+	case Tiling_Mercator:
+		return "MERCATOR"
+	case Tiling_Raster:
+		return "RASTER"
+	}
+	return "MERCATOR"
+}
+
+// PyramidOptions are the options of GeneratePyramid.
+//
+// With Tiling_Raster, the zoom level of the full resolution is NativeZoom,
+// and the zoom level 0 is the overview fitting in one tile.
+type PyramidOptions struct {
+	Tiling       Tiling
+	MinZoom      int
+	MaxZoom      *int              // nil means the native zoom level
+	Format       string            // "png" (default) or "jpg"
+	TileSize     int               // 256 by default
+	ResampleType gdal.ResampleType // resampling of the overviews and the tiles
+	JPEGQuality  int               // 75 by default
+	Concurrency  int               // number of the rendering goroutines, 0 means runtime.NumCPU()
+	TMS          bool              // y origin at the bottom, only for the directory output
+}
+
+// tileSource renders the tiles of a pyramid.
+type tileSource interface {
+	NativeZoom() int
+	TileRange(z int) image.Rectangle
+	Tile(z, x, y int) (*image.NRGBA, error)
+	Close() error
+}
+
+// tileWriter writes the rendered tiles, y is the XYZ tile row.
+type tileWriter interface {
+	WriteTile(z, x, y int, m *image.NRGBA) error
+	Close() error
+}
+
+// GeneratePyramid renders the tile pyramid of the 8-bit raster file.
+//
+// If output has the ".mbtiles" extension, the tiles are written to a
+// MBTiles file (Tiling_Mercator only). Otherwise the tiles are written to
+// the "{output}/{z}/{x}/{y}.{format}" files.
+//
+// The overviews of the raster are built if not exist, the empty tiles
+// (fully transparent) are skipped.
+func GeneratePyramid(filename, output string, opt *PyramidOptions) (err error) {
+	var o PyramidOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.TileSize <= 0 {
+		o.TileSize = 256
+	}
+	if o.JPEGQuality <= 0 {
+		o.JPEGQuality = 75
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = runtime.NumCPU()
+	}
+	switch o.Format = strings.ToLower(o.Format); o.Format {
+	case "":
+		o.Format = "png"
+	case "jpeg":
+		o.Format = "jpg"
+	case "png", "jpg":
+	default:
+		return fmt.Errorf("tileserver: GeneratePyramid(%q), unsupported format: %q", filename, o.Format)
+	}
+
+	isMBTiles := strings.EqualFold(filepath.Ext(output), ".mbtiles")
+	if isMBTiles && o.Tiling != Tiling_Mercator {
+		return fmt.Errorf("tileserver: GeneratePyramid(%q), MBTiles only supports Tiling_Mercator.", filename)
+	}
+
+	ds, err := gdal.OpenDataset(filename, gdal.GA_ReadOnly)
+	if err != nil {
+		return err
+	}
+	if err = ds.SetResampleType(o.ResampleType); err == nil {
+		err = ds.BuildOverviewsIfNotExists()
+	}
+	ds.Close()
+	if err != nil {
+		return err
+	}
+
+	var src tileSource
+	switch o.Tiling {
+	case Tiling_Mercator:
+		src, err = New(filename, &Options{
+			TileSize:     o.TileSize,
+			ResampleType: o.ResampleType,
+			PoolSize:     o.Concurrency,
+		})
+	case Tiling_Raster:
+		src, err = newRasterSource(filename, &o)
+	default:
+		err = fmt.Errorf("tileserver: GeneratePyramid(%q), unknown tiling: %d", filename, int(o.Tiling))
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	minZoom, maxZoom := o.MinZoom, src.NativeZoom()
+	if o.MaxZoom != nil {
+		maxZoom = *o.MaxZoom
+	}
+	if o.Tiling == Tiling_Raster && maxZoom > src.NativeZoom() {
+		maxZoom = src.NativeZoom()
+	}
+	if minZoom < 0 || minZoom > maxZoom || maxZoom > MaxZoom {
+		return fmt.Errorf("tileserver: GeneratePyramid(%q), bad zoom range: [%d, %d]", filename, minZoom, maxZoom)
+	}
+
+	var w tileWriter
+	if isMBTiles {
+		if w, err = newMBTilesWriter(output, src.(*Server), minZoom, maxZoom, &o); err != nil {
+			return err
+		}
+		// the lower zoom levels are built by the MBTiles driver
+		minZoom = maxZoom
+	} else {
+		w = &dirWriter{dir: output, ext: o.Format, quality: o.JPEGQuality, tms: o.TMS}
+	}
+	defer func() {
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return renderPyramid(src, w, minZoom, maxZoom, &o)
+}
+
+func renderPyramid(src tileSource, w tileWriter, minZoom, maxZoom int, opt *PyramidOptions) error {
+	type tileIndex struct{ z, x, y int }
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		done     = make(chan struct{})
+		jobs     = make(chan tileIndex)
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(done)
+		})
+	}
+
+	for i := 0; i < opt.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				m, err := src.Tile(t.z, t.x, t.y)
+				if err == ErrTileNotFound || (err == nil && isTransparent(m)) {
+					continue
+				}
+				if err != nil {
+					setErr(err)
+					continue
+				}
+				if err := w.WriteTile(t.z, t.x, t.y, m); err != nil {
+					setErr(err)
+				}
+			}
+		}()
+	}
+
+loop:
+	for z := minZoom; z <= maxZoom; z++ {
+		r := src.TileRange(z)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				select {
+				case jobs <- tileIndex{z, x, y}:
+				case <-done:
+					break loop
+				}
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
+func isTransparent(m *image.NRGBA) bool {
+	for i := 3; i < len(m.Pix); i += 4 {
+		if m.Pix[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// rasterSource renders the raster pixel tiles by ReadOverview.
+type rasterSource struct {
+	pool       *gdal.DatasetPool
+	tileSize   int
+	nativeZoom int
+	hasAlpha   bool
+	nodata     *float64
+}
+
+func newRasterSource(filename string, opt *PyramidOptions) (p *rasterSource, err error) {
+	pool, err := gdal.OpenDatasetPool(filename, &gdal.DatasetPoolOptions{
+		MaxSize:      opt.Concurrency,
+		ResampleType: opt.ResampleType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pool.DataType() != reflect.Uint8 {
+		pool.Close()
+		return nil, fmt.Errorf("tileserver: newRasterSource(%q), unsupported data type: %v", filename, pool.DataType())
+	}
+
+	p = &rasterSource{
+		pool:     pool,
+		tileSize: opt.TileSize,
+		nodata:   pool.Opt.NoData,
+	}
+	for p.tileSize<<uint(p.nativeZoom) < pool.Width() || p.tileSize<<uint(p.nativeZoom) < pool.Height() {
+		p.nativeZoom++
+	}
+
+	err = pool.Do(func(ds *gdal.Dataset) error {
+		if band, err := ds.Band(0); err == nil && ds.Channels() == 1 && band.ColorInterpretation() == gdal.GCI_PaletteIndex {
+			return fmt.Errorf("tileserver: newRasterSource(%q), paletted raster is not supported, use Tiling_Mercator or expand it to RGBA", filename)
+		}
+		if n := ds.Channels(); n == 2 || n == 4 {
+			band, err := ds.Band(n - 1)
+			if err != nil {
+				return err
+			}
+			p.hasAlpha = band.ColorInterpretation() == gdal.GCI_AlphaBand
+		}
+		return nil
+	})
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *rasterSource) NativeZoom() int {
+	return p.nativeZoom
+}
+
+func (p *rasterSource) TileRange(z int) image.Rectangle {
+	size := float64(p.tileSize << uint(p.nativeZoom-z))
+	return image.Rect(0, 0,
+		int(math.Ceil(float64(p.pool.Width())/size)),
+		int(math.Ceil(float64(p.pool.Height())/size)),
+	)
+}
+
+func (p *rasterSource) Tile(z, x, y int) (*image.NRGBA, error) {
+	if z < 0 || z > p.nativeZoom || !image.Pt(x, y).In(p.TileRange(z)) {
+		return nil, ErrTileNotFound
+	}
+	ts := p.tileSize
+	m, err := p.pool.ReadOverview(p.nativeZoom-z, image.Rect(x*ts, y*ts, (x+1)*ts, (y+1)*ts))
+	if err != nil {
+		return nil, err
+	}
+	return toNRGBA(m.(*gdal.MemPImage), ts, p.hasAlpha, p.nodata), nil
+}
+
+func (p *rasterSource) Close() error {
+	return p.pool.Close()
+}
+
+// dirWriter writes the tiles to "{dir}/{z}/{x}/{y}.{ext}".
+type dirWriter struct {
+	dir     string
+	ext     string
+	quality int // jpeg quality
+	tms     bool
+}
+
+func (p *dirWriter) WriteTile(z, x, y int, m *image.NRGBA) error {
+	data, err := encodeTile(m, p.ext, p.quality)
+	if err != nil {
+		return err
+	}
+	if p.tms {
+		y = FlipY(z, y)
+	}
+	dir := filepath.Join(p.dir, strconv.Itoa(z), strconv.Itoa(x))
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, strconv.Itoa(y)+"."+p.ext), data, 0666)
+}
+
+func (p *dirWriter) Close() error {
+	return nil
+}
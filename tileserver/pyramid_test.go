@@ -0,0 +1,132 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tileserver_test
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chai2010/gdal"
+	"github.com/chai2010/gdal/tileserver"
+)
+
+func TestGeneratePyramid_raster(t *testing.T) {
+	dir, err := ioutil.TempDir("", "z_test_TestGeneratePyramid_raster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// copy the testdata, the overviews are built beside it
+	m, err := gdal.Load("../testdata/lena512color.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "lena.tiff")
+	if err := gdal.Save(filename, m, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "tiles")
+	err = tileserver.GeneratePyramid(filename, output, &tileserver.PyramidOptions{
+		Tiling: tileserver.Tiling_Raster,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"0/0/0.png", "1/0/0.png", "1/1/1.png"} {
+		if _, err := os.Stat(filepath.Join(output, s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(output, "2")); err == nil {
+		t.Fatal("expect no zoom level 2")
+	}
+}
+
+func TestGeneratePyramid_mercator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "z_test_TestGeneratePyramid_mercator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "world.tiff")
+	tCreateWorld(t, filename)
+
+	maxZoom := 1
+	output := filepath.Join(dir, "tiles")
+	err = tileserver.GeneratePyramid(filename, output, &tileserver.PyramidOptions{
+		MinZoom: 0,
+		MaxZoom: &maxZoom,
+		Format:  "jpg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"0/0/0.jpg", "1/0/0.jpg", "1/1/1.jpg"} {
+		if _, err := os.Stat(filepath.Join(output, s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mbtiles := filepath.Join(dir, "world.mbtiles")
+	err = tileserver.GeneratePyramid(filename, mbtiles, &tileserver.PyramidOptions{
+		MaxZoom: &maxZoom,
+	})
+	if err != nil && strings.Contains(err.Error(), `unknown driver "MBTiles"`) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := gdal.OpenDataset(mbtiles, gdal.GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	md := f.GetMetadata("")
+	for k, v := range map[string]string{
+		"name":    "world",
+		"type":    "baselayer",
+		"format":  "png",
+		"minzoom": "0",
+		"maxzoom": "1",
+	} {
+		if md[k] != v {
+			t.Fatalf("metadata %q: expect = %q, got = %q", k, v, md[k])
+		}
+	}
+	if _, ok := md["bounds"]; !ok {
+		t.Fatal("missing bounds metadata")
+	}
+
+	// the zoom level 1 is 2x2 tiles, the zoom level 0 is the overview
+	if f.Width() != 512 || f.Height() != 512 || f.Channels() != 4 {
+		t.Fatalf("bad size: %dx%dx%d", f.Width(), f.Height(), f.Channels())
+	}
+	if !f.HasOverviews() {
+		t.Fatal("expect zoom level 0")
+	}
+	m, err := f.Read(image.Rect(0, 0, f.Width(), f.Height()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pt := range []image.Point{{128, 256}, {384, 256}} {
+		if _, _, _, a := m.At(pt.X, pt.Y).RGBA(); a == 0 {
+			t.Fatalf("expect opaque pixel at %v", pt)
+		}
+	}
+	if _, _, _, a := m.At(256, 0).RGBA(); a != 0 {
+		t.Fatal("expect transparent pixel above 80 degree")
+	}
+}
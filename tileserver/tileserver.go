@@ -0,0 +1,434 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tileserver provides a XYZ/TMS map tile server of GDAL raster.
+//
+// Example:
+//
+//	s, err := tileserver.New("world.tiff", nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer s.Close()
+//
+//	http.Handle("/tiles/", http.StripPrefix("/tiles", s))
+//	log.Fatal(http.ListenAndServe(":8080", nil))
+package tileserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chai2010/gdal"
+)
+
+// ErrTileNotFound is returned for the tiles outside of the dataset.
+var ErrTileNotFound = errors.New("tileserver: tile not found")
+
+// Options are the options of the Server.
+type Options struct {
+	TileSize     int               // 256 by default
+	TMS          bool              // y origin at the bottom (TMS), otherwise at the top (XYZ)
+	ResampleType gdal.ResampleType // ResampleType_Bilinear by default
+	JPEGQuality  int               // 75 by default
+	CacheSize    int               // number of the cached tiles, 0 disables the cache
+	MaxAge       time.Duration     // Cache-Control max-age, 0 means no header
+	PoolSize     int               // number of the GDAL handles, see gdal.DatasetPoolOptions
+}
+
+// Server serves the tiles of a georeferenced 8-bit raster in Web Mercator,
+// the URL path is "/{z}/{x}/{y}.png" or "/{z}/{x}/{y}.jpg".
+//
+// The tiles are read from a warped VRT (in Web Mercator, with an alpha band)
+// of the raster, which uses the overviews of the raster for the tiles of
+// the lower zoom levels.
+//
+// The paletted raster is expanded to RGBA before warping. The NoData pixels
+// and the area outside of the raster are transparent, and are white in the
+// jpg tiles.
+type Server struct {
+	opt        Options
+	vrtName    string            // the /vsimem warped VRT
+	expandName string            // the /vsimem VRT of the expanded palette
+	pool       *gdal.DatasetPool // handles of the warped VRT
+	bounds     gdal.GeoRect      // in Web Mercator
+	nativeZoom int
+	cache      *tileCache
+}
+
+// New opens the raster file and creates a tile server.
+func New(filename string, opt *Options) (s *Server, err error) {
+	s = new(Server)
+	if opt != nil {
+		s.opt = *opt
+	}
+	if s.opt.TileSize <= 0 {
+		s.opt.TileSize = 256
+	}
+	if s.opt.ResampleType == gdal.ResampleType_Nil {
+		s.opt.ResampleType = gdal.ResampleType_Bilinear
+	}
+	if s.opt.JPEGQuality <= 0 {
+		s.opt.JPEGQuality = jpeg.DefaultQuality
+	}
+	if s.opt.CacheSize > 0 {
+		s.cache = newTileCache(s.opt.CacheSize)
+	}
+
+	if err = s.init(filename); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("tileserver: New(%q) failed: %w", filename, err)
+	}
+	return s, nil
+}
+
+func (s *Server) init(filename string) (err error) {
+	// the warped VRT references the raster by the filename
+	if _, err := os.Stat(filename); err == nil {
+		if abs, err := filepath.Abs(filename); err == nil {
+			filename = abs
+		}
+	}
+	src, err := gdal.OpenDataset(filename, gdal.GA_ReadOnly)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if src.Opt.Projection == "" {
+		return fmt.Errorf("no projection")
+	}
+	if src.DataType() != reflect.Uint8 {
+		return fmt.Errorf("unsupported data type: %v", src.DataType())
+	}
+
+	if band, err := src.Band(0); err == nil && src.Channels() == 1 && band.ColorInterpretation() == gdal.GCI_PaletteIndex {
+		// the colors are warped, not the palette indices
+		s.expandName = gdal.VSITempName() + ".vrt"
+		expanded, err := gdal.Translate(s.expandName, src, &gdal.TranslateOptions{
+			Opt:     &gdal.Options{DriverName: "VRT"},
+			ExtArgs: []string{"-expand", "rgba"},
+		})
+		if err != nil {
+			return err
+		}
+		if err = expanded.Close(); err != nil {
+			return err
+		}
+		if src, err = gdal.OpenDataset(s.expandName, gdal.GA_ReadOnly); err != nil {
+			return err
+		}
+		defer src.Close()
+	}
+
+	hasAlpha := false
+	if n := src.Channels(); n == 2 || n == 4 {
+		band, err := src.Band(n - 1)
+		if err != nil {
+			return err
+		}
+		hasAlpha = band.ColorInterpretation() == gdal.GCI_AlphaBand
+	}
+
+	merc, err := gdal.NewSpatialReferenceFromEPSG(3857)
+	if err != nil {
+		return err
+	}
+	defer merc.Close()
+
+	s.vrtName = gdal.VSITempName() + ".vrt"
+	vrt, err := gdal.Warp(s.vrtName, src, &gdal.WarpOptions{
+		DstSRS:       merc,
+		ResampleType: s.opt.ResampleType,
+		DstAlpha:     !hasAlpha,
+		Opt:          &gdal.Options{DriverName: "VRT"},
+	})
+	if err != nil {
+		return err
+	}
+	s.bounds = vrt.GeoBounds()
+	s.nativeZoom = zoomForResolution(vrt.Opt.Transform[1], s.opt.TileSize)
+	if err = vrt.Close(); err != nil {
+		return err
+	}
+
+	s.pool, err = gdal.OpenDatasetPool(s.vrtName, &gdal.DatasetPoolOptions{
+		MaxSize:     s.opt.PoolSize,
+		IdleTimeout: time.Minute,
+	})
+	return err
+}
+
+// Close closes the GDAL handles of the server.
+func (s *Server) Close() error {
+	var err error
+	if s.pool != nil {
+		err = s.pool.Close()
+	}
+	if s.vrtName != "" {
+		gdal.VSIUnlink(s.vrtName)
+	}
+	if s.expandName != "" {
+		gdal.VSIUnlink(s.expandName)
+	}
+	return err
+}
+
+// Bounds returns the bounds of the raster in Web Mercator.
+func (s *Server) Bounds() gdal.GeoRect {
+	return s.bounds
+}
+
+// NativeZoom returns the zoom level of the native resolution of the raster.
+func (s *Server) NativeZoom() int {
+	return s.nativeZoom
+}
+
+// TileRange returns the x/y range of the XYZ tiles covering the raster.
+func (s *Server) TileRange(z int) image.Rectangle {
+	n := 1 << uint(z)
+	size := 2 * OriginShift / float64(n)
+	r := image.Rect(
+		int(math.Floor((s.bounds.MinX+OriginShift)/size)),
+		int(math.Floor((OriginShift-s.bounds.MaxY)/size)),
+		int(math.Ceil((s.bounds.MaxX+OriginShift)/size)),
+		int(math.Ceil((OriginShift-s.bounds.MinY)/size)),
+	)
+	return r.Intersect(image.Rect(0, 0, n, n))
+}
+
+// Tile renders the XYZ tile (not TMS), ErrTileNotFound is returned if the
+// tile is outside of the raster.
+func (s *Server) Tile(z, x, y int) (*image.NRGBA, error) {
+	if z < 0 || z > MaxZoom || x < 0 || y < 0 || x >= 1<<uint(z) || y >= 1<<uint(z) {
+		return nil, ErrTileNotFound
+	}
+	b := TileBounds(z, x, y)
+	if b.Intersect(s.bounds).Empty() {
+		return nil, ErrTileNotFound
+	}
+
+	ts := s.opt.TileSize
+	dst := image.NewNRGBA(image.Rect(0, 0, ts, ts))
+
+	// the tile in the pixel coordinates of the warped VRT
+	gt := s.pool.Opt.Transform
+	fx0, fx1 := (b.MinX-gt[0])/gt[1], (b.MaxX-gt[0])/gt[1]
+	fy0, fy1 := (b.MaxY-gt[3])/gt[5], (b.MinY-gt[3])/gt[5]
+	sx, sy := float64(ts)/(fx1-fx0), float64(ts)/(fy1-fy0)
+
+	r := image.Rect(
+		int(math.Floor(fx0)), int(math.Floor(fy0)),
+		int(math.Ceil(fx1)), int(math.Ceil(fy1)),
+	).Intersect(image.Rect(0, 0, s.pool.Width(), s.pool.Height()))
+
+	// the r in the tile, which may be larger than the tile
+	dr := image.Rect(
+		int(math.Round((float64(r.Min.X)-fx0)*sx)), int(math.Round((float64(r.Min.Y)-fy0)*sy)),
+		int(math.Round((float64(r.Max.X)-fx0)*sx)), int(math.Round((float64(r.Max.Y)-fy0)*sy)),
+	)
+	if r.Empty() || dr.Empty() {
+		return dst, nil
+	}
+
+	// the over-zoomed tile is read at most twice of the tile size, and then
+	// scaled by the nearest neighbour
+	size := dr.Size()
+	if size.X > 2*ts {
+		size.X = 2 * ts
+	}
+	if size.Y > 2*ts {
+		size.Y = 2 * ts
+	}
+	m, err := s.pool.ReadToSize(r, size)
+	if err != nil {
+		return nil, err
+	}
+	drawNRGBA(dst, dr, m.(*gdal.MemPImage), true, nil)
+	return dst, nil
+}
+
+// toNRGBA converts the 8-bit gray/RGB image (with the last alpha channel
+// if hasAlpha) to a size x size tile, the pixels outside of m are transparent.
+//
+// If nodata is not nil, the pixels whose color channels are all nodata are
+// transparent.
+func toNRGBA(m *gdal.MemPImage, size int, hasAlpha bool, nodata *float64) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	drawNRGBA(dst, image.Rect(0, 0, m.Bounds().Dx(), m.Bounds().Dy()), m, hasAlpha, nodata)
+	return dst
+}
+
+// drawNRGBA draws the 8-bit gray/RGB image m (see toNRGBA) to the dr of dst,
+// m is scaled by the nearest neighbour if the sizes are different.
+func drawNRGBA(dst *image.NRGBA, dr image.Rectangle, m *gdal.MemPImage, hasAlpha bool, nodata *float64) {
+	b := m.Bounds()
+	r := dr.Intersect(dst.Bounds())
+	if r.Empty() || b.Empty() {
+		return
+	}
+
+	c, nc := m.XChannels, m.XChannels
+	if hasAlpha {
+		nc--
+	}
+	if nc >= 3 {
+		nc = 3
+	} else {
+		nc = 1
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := (y - dr.Min.Y) * b.Dy() / dr.Dy()
+		src := m.XPix[sy*m.XStride:][:b.Dx()*c]
+		off := dst.PixOffset(r.Min.X, y)
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := (x - dr.Min.X) * b.Dx() / dr.Dx()
+			pix := src[sx*c:][:c]
+			if nc == 3 {
+				copy(dst.Pix[off:][:3], pix[:3])
+			} else {
+				dst.Pix[off+0] = pix[0]
+				dst.Pix[off+1] = pix[0]
+				dst.Pix[off+2] = pix[0]
+			}
+			if hasAlpha {
+				dst.Pix[off+3] = pix[c-1]
+			} else {
+				dst.Pix[off+3] = 0xFF
+			}
+			if nodata != nil && isNoData(pix[:nc], *nodata) {
+				dst.Pix[off+3] = 0
+			}
+			off += 4
+		}
+	}
+}
+
+func isNoData(pix []byte, nodata float64) bool {
+	for _, v := range pix {
+		if float64(v) != nodata {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeHTTP serves "/{z}/{x}/{y}.png" and "/{z}/{x}/{y}.jpg".
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	z, x, y, ext, err := parseTilePath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.opt.TMS && z >= 0 && z <= MaxZoom {
+		y = FlipY(z, y)
+	}
+
+	data, err := s.encodedTile(z, x, y, ext)
+	if err == ErrTileNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if ext == "png" {
+		w.Header().Set("Content-Type", "image/png")
+	} else {
+		w.Header().Set("Content-Type", "image/jpeg")
+	}
+	if s.opt.MaxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.opt.MaxAge/time.Second)))
+	}
+	w.Write(data)
+}
+
+func (s *Server) encodedTile(z, x, y int, ext string) ([]byte, error) {
+	key := fmt.Sprintf("%d/%d/%d.%s", z, x, y, ext)
+	if s.cache != nil {
+		if data, ok := s.cache.Get(key); ok {
+			return data, nil
+		}
+	}
+
+	m, err := s.Tile(z, x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := encodeTile(m, ext, s.opt.JPEGQuality)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cache != nil {
+		s.cache.Set(key, data)
+	}
+	return data, nil
+}
+
+// encodeTile encodes the tile as "png" or "jpg", the jpg has no alpha, so
+// the tile is blended onto a white background.
+func encodeTile(m image.Image, ext string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if ext == "png" {
+		err = png.Encode(&buf, m)
+	} else {
+		if o, ok := m.(interface{ Opaque() bool }); !ok || !o.Opaque() {
+			bg := image.NewRGBA(m.Bounds())
+			draw.Draw(bg, bg.Rect, image.White, image.Point{}, draw.Src)
+			draw.Draw(bg, bg.Rect, m, bg.Rect.Min, draw.Over)
+			m = bg
+		}
+		err = jpeg.Encode(&buf, m, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseTilePath parses "/{z}/{x}/{y}.{ext}", ext is "png" or "jpg".
+func parseTilePath(path string) (z, x, y int, ext string, err error) {
+	ss := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ss) != 3 {
+		return 0, 0, 0, "", fmt.Errorf("tileserver: bad tile path: %q", path)
+	}
+	i := strings.LastIndexByte(ss[2], '.')
+	if i < 0 {
+		return 0, 0, 0, "", fmt.Errorf("tileserver: bad tile path: %q", path)
+	}
+	ss[2], ext = ss[2][:i], strings.ToLower(ss[2][i+1:])
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+	if ext != "png" && ext != "jpg" {
+		return 0, 0, 0, "", fmt.Errorf("tileserver: unsupported tile format: %q", ext)
+	}
+
+	var v [3]int
+	for i, s := range ss {
+		if v[i], err = strconv.Atoi(s); err != nil {
+			return 0, 0, 0, "", fmt.Errorf("tileserver: bad tile path: %q", path)
+		}
+	}
+	return v[0], v[1], v[2], ext, nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tileserver_test
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chai2010/gdal"
+	"github.com/chai2010/gdal/tileserver"
+)
+
+// tCreateWorld creates a gray raster of the world in EPSG:4326, the
+// latitude is in [-80, 80].
+func tCreateWorld(t *testing.T, filename string) {
+	wgs84, err := gdal.NewSpatialReferenceFromEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wgs84.Close()
+
+	wkt, err := wgs84.ExportToWkt()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodata := 0.0
+	m := gdal.NewMemPImage(image.Rect(0, 0, 360, 160), 1, reflect.Uint8)
+	for i := range m.XPix {
+		m.XPix[i] = 128
+	}
+	m.XNoData = &nodata
+
+	err = gdal.Save(filename, m, &gdal.Options{
+		Projection: wkt,
+		Transform:  [6]float64{-180, 1, 0, 80, 0, -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	tmpname := "z_test_TestServer.tiff"
+	defer os.Remove(tmpname)
+	tCreateWorld(t, tmpname)
+
+	s, err := tileserver.New(tmpname, &tileserver.Options{CacheSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/0/0/0.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expect = %d, got = %d", http.StatusOK, resp.StatusCode)
+	}
+	if v := resp.Header.Get("Content-Type"); v != "image/png" {
+		t.Fatalf("expect = %q, got = %q", "image/png", v)
+	}
+	m, err := png.Decode(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := m.Bounds(); v != image.Rect(0, 0, 256, 256) {
+		t.Fatalf("expect = %v, got = %v", image.Rect(0, 0, 256, 256), v)
+	}
+
+	// center is inside, the top and bottom edges (> 80 degree) are outside,
+	// 80 degree is at about 28.7 pixel from the edges of the z0 tile
+	if _, _, _, a := m.At(128, 128).RGBA(); a == 0 {
+		t.Fatal("expect opaque center")
+	}
+	for _, y := range []int{0, 27, 256 - 28, 255} {
+		if _, _, _, a := m.At(128, y).RGBA(); a != 0 {
+			t.Fatalf("expect transparent edge at y = %d", y)
+		}
+	}
+	for _, y := range []int{30, 256 - 31} {
+		if _, _, _, a := m.At(128, y).RGBA(); a == 0 {
+			t.Fatalf("expect opaque pixel at y = %d", y)
+		}
+	}
+
+	for path, status := range map[string]int{
+		"/0/0/0.jpg":  http.StatusOK,
+		"/1/0/0.png":  http.StatusOK,
+		"/1/2/0.png":  http.StatusNotFound,
+		"/0/0/0.gif":  http.StatusBadRequest,
+		"/0/0.png":    http.StatusBadRequest,
+		"/a/b/c.png":  http.StatusBadRequest,
+		"/-1/0/0.png": http.StatusNotFound,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s: expect = %d, got = %d", path, status, resp.StatusCode)
+		}
+	}
+}
+
+func TestTileBounds(t *testing.T) {
+	b := tileserver.TileBounds(1, 1, 0)
+	if b.MinX != 0 || b.MinY != 0 || b.MaxX != tileserver.OriginShift || b.MaxY != tileserver.OriginShift {
+		t.Fatalf("bad bounds: %v", b)
+	}
+	if v := tileserver.Resolution(0, 256); math.Abs(v-156543.03392804097) > 1e-6 {
+		t.Fatalf("bad resolution: %v", v)
+	}
+	if v := tileserver.FlipY(2, 0); v != 3 {
+		t.Fatalf("expect = 3, got = %d", v)
+	}
+}
+
+func TestServer_jpeg(t *testing.T) {
+	tmpname := "z_test_TestServer_jpeg.tiff"
+	defer os.Remove(tmpname)
+	tCreateWorld(t, tmpname)
+
+	s, err := tileserver.New(tmpname, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/0/0/0.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	m, err := jpeg.Decode(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the transparent edge is white, the center is gray
+	if r, g, b, _ := m.At(128, 0).RGBA(); r>>8 < 0xF0 || g>>8 < 0xF0 || b>>8 < 0xF0 {
+		t.Fatalf("expect white edge, got %v", m.At(128, 0))
+	}
+	if r, _, _, _ := m.At(128, 128).RGBA(); r>>8 < 0x70 || r>>8 > 0x90 {
+		t.Fatalf("expect gray center, got %v", m.At(128, 128))
+	}
+}
+
+func TestServer_paletted(t *testing.T) {
+	tmpname := "z_test_TestServer_paletted.tiff"
+	defer os.Remove(tmpname)
+
+	wgs84, err := gdal.NewSpatialReferenceFromEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wgs84.Close()
+
+	wkt, err := wgs84.ExportToWkt()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pal := color.Palette{color.RGBA{0, 0, 0, 0xFF}, color.RGBA{0xFF, 0, 0, 0xFF}}
+	m := image.NewPaletted(image.Rect(0, 0, 360, 160), pal)
+	for i := range m.Pix {
+		m.Pix[i] = 1
+	}
+	err = gdal.Save(tmpname, m, &gdal.Options{
+		Projection: wkt,
+		Transform:  [6]float64{-180, 1, 0, 80, 0, -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := tileserver.New(tmpname, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	tile, err := s.Tile(0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := tile.NRGBAAt(128, 128); c != (color.NRGBA{0xFF, 0, 0, 0xFF}) {
+		t.Fatalf("expect red center, got %v", c)
+	}
+}
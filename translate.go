@@ -0,0 +1,169 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+/*
+#include <gdal.h>
+#include <gdal_version.h>
+#include <cpl_string.h>
+#include <stdlib.h>
+
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(2,1,0)
+#include <gdal_utils.h>
+#endif
+
+// translate is GDALTranslate of gdal_utils.h with the gdal_translate
+// arguments (GDAL >= 2.1).
+static GDALDatasetH translate(const char *pszDest, GDALDatasetH hSrcDS, char **papszArgv, int *pbUsageError) {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(2,1,0)
+	GDALDatasetH hDstDS;
+	GDALTranslateOptions *psOptions = GDALTranslateOptionsNew(papszArgv, NULL);
+	if(psOptions == NULL) {
+		*pbUsageError = TRUE;
+		return NULL;
+	}
+	hDstDS = GDALTranslate(pszDest, hSrcDS, psOptions, pbUsageError);
+	GDALTranslateOptionsFree(psOptions);
+	return hDstDS;
+#else
+	CPLError(CE_Failure, CPLE_NotSupported, "GDALTranslate() requires GDAL >= 2.1");
+	return NULL;
+#endif
+}
+*/
+import "C"
+import (
+	"fmt"
+	"image"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// ScaleRange rescales the pixel values from [SrcMin, SrcMax] to [DstMin, DstMax].
+//
+// The zero ScaleRange means the source range is computed from the data and
+// the destination range is [0, 255].
+type ScaleRange struct {
+	SrcMin, SrcMax float64
+	DstMin, DstMax float64
+}
+
+// TranslateOptions are the options of Translate (see gdal_translate).
+//
+// SrcWindow and ProjWindow are exclusive. Bands are indices of the source
+// bands in [0, Channels()), and can be used to select or reorder bands.
+// If Opt is nil or Opt.DriverName is empty, the output driver is guessed
+// from the filename (or "MEM" for empty filename).
+type TranslateOptions struct {
+	SrcWindow    image.Rectangle // -srcwin xoff yoff xsize ysize
+	ProjWindow   *GeoRect        // -projwin ulx uly lrx lry
+	Width        int             // -outsize width height
+	Height       int             // -outsize width height
+	Bands        []int           // -b band
+	DataType     reflect.Kind    // -ot, reflect.Invalid means same as the source
+	Scale        *ScaleRange     // -scale
+	NoData       *float64        // -a_nodata
+	ResampleType ResampleType    // -r, the warp only types (such as MIN, Q1) are not supported
+	Opt          *Options        // -of, -co
+	ExtArgs      []string        // other gdal_translate arguments, such as "-stats"
+}
+
+// Translate converts the src dataset to filename, such as subsetting,
+// resampling and rescaling.
+//
+// If filename is empty, an in-memory dataset is returned.
+func Translate(filename string, src *Dataset, opt *TranslateOptions) (p *Dataset, err error) {
+	if opt == nil {
+		opt = new(TranslateOptions)
+	}
+	args, err := opt.args(filename, src._Channels)
+	if err != nil {
+		return nil, fmt.Errorf("gdal: Translate(%q) failed: %w", filename, err)
+	}
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	papszArgv := cStringArray(args)
+	defer C.CSLDestroy(papszArgv)
+
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+
+	var (
+		poDataset   C.GDALDatasetH
+		bUsageError C.int
+	)
+	cplErr := cplCapture(func() {
+		poDataset = C.translate(cname, src.poDataset, papszArgv, &bUsageError)
+	})
+	if bUsageError != 0 {
+		if poDataset != nil {
+			C.GDALClose(poDataset)
+		}
+		return nil, cplErrorf(cplErr, "gdal: Translate(%q), bad options: %v", filename, args)
+	}
+	if poDataset == nil {
+		return nil, cplErrorf(cplErr, "gdal: Translate(%q) failed", filename)
+	}
+	return newDatasetFromHandle(filename, poDataset, GA_Update), nil
+}
+
+func (opt *TranslateOptions) args(filename string, channels int) (args []string, err error) {
+	if !opt.SrcWindow.Empty() && opt.ProjWindow != nil {
+		return nil, fmt.Errorf("gdal: TranslateOptions, SrcWindow and ProjWindow are exclusive.")
+	}
+	if r := opt.SrcWindow; !r.Empty() {
+		args = append(args, "-srcwin",
+			strconv.Itoa(r.Min.X), strconv.Itoa(r.Min.Y),
+			strconv.Itoa(r.Dx()), strconv.Itoa(r.Dy()),
+		)
+	}
+	if r := opt.ProjWindow; r != nil {
+		args = append(args, "-projwin",
+			ftoa(r.MinX), ftoa(r.MaxY),
+			ftoa(r.MaxX), ftoa(r.MinY),
+		)
+	}
+	if opt.Width != 0 || opt.Height != 0 {
+		args = append(args, "-outsize", strconv.Itoa(opt.Width), strconv.Itoa(opt.Height))
+	}
+	for _, i := range opt.Bands {
+		if i < 0 || i >= channels {
+			return nil, fmt.Errorf("gdal: TranslateOptions, band index out of range: %d", i)
+		}
+		args = append(args, "-b", strconv.Itoa(i+1))
+	}
+	if opt.DataType != reflect.Invalid {
+		eType := gdalDataType(opt.DataType)
+		if eType == C.GDT_Unknown {
+			return nil, fmt.Errorf("gdal: TranslateOptions, unsupported data type: %v", opt.DataType)
+		}
+		args = append(args, "-ot", C.GoString(C.GDALGetDataTypeName(eType)))
+	}
+	if s := opt.Scale; s != nil {
+		if *s == (ScaleRange{}) {
+			args = append(args, "-scale")
+		} else {
+			args = append(args, "-scale",
+				ftoa(s.SrcMin), ftoa(s.SrcMax),
+				ftoa(s.DstMin), ftoa(s.DstMax),
+			)
+		}
+	}
+	if opt.NoData != nil {
+		args = append(args, "-a_nodata", ftoa(*opt.NoData))
+	}
+	if !opt.ResampleType.isTranslateResampling() {
+		return nil, fmt.Errorf("gdal: TranslateOptions, unsupported resample type: %s", opt.ResampleType.Name())
+	}
+	if opt.ResampleType != ResampleType_Nil {
+		args = append(args, "-r", opt.ResampleType.warpName())
+	}
+	args = append(args, outputArgs(filename, opt.Opt)...)
+	args = append(args, opt.ExtArgs...)
+	return args, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestTranslate_mem(t *testing.T) {
+	src, err := OpenDataset("./testdata/video-001.tiff", GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	dst, err := Translate("", src, &TranslateOptions{
+		SrcWindow: image.Rect(10, 20, 110, 70),
+		Bands:     []int{2, 1, 0},
+		DataType:  reflect.Float32,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	if dst.Width() != 100 || dst.Height() != 50 || dst.Channels() != 3 || dst.DataType() != reflect.Float32 {
+		t.Fatalf("bad dst: %dx%dx%d, %v", dst.Width(), dst.Height(), dst.Channels(), dst.DataType())
+	}
+
+	m0, err := src.Read(image.Rect(10, 20, 110, 70))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1, err := dst.Read(image.Rect(0, 0, 100, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, p1 := m0.(*MemPImage), m1.(*MemPImage)
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 100; x++ {
+			c0 := p0.PixelAt(10+x, 20+y)
+			c1 := PixSlice(p1.PixelAt(x, y)).Float32s()
+			if float32(c0[2]) != c1[0] || float32(c0[0]) != c1[2] {
+				t.Fatalf("(%d, %d): src = %v, dst = %v", x, y, c0, c1)
+			}
+		}
+	}
+}
+
+func TestTranslate_resampleType(t *testing.T) {
+	src, err := CreateDataset("", 4, 4, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	for _, v := range []ResampleType{ResampleType_Min, ResampleType_Max, ResampleType_Q1, ResampleType_Gauss} {
+		if _, err := Translate("", src, &TranslateOptions{Width: 2, Height: 2, ResampleType: v}); err == nil {
+			t.Fatalf("%s: expect error", v.Name())
+		}
+	}
+	dst, err := Translate("", src, &TranslateOptions{Width: 2, Height: 2, ResampleType: ResampleType_Average})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst.Close()
+}
@@ -0,0 +1,562 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+/*
+#include <gdal.h>
+#include <gdal_version.h>
+#include <cpl_string.h>
+#include <stdlib.h>
+
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(2,1,0)
+#include <gdal_utils.h>
+#endif
+
+// buildVRT is GDALBuildVRT of gdal_utils.h with the gdalbuildvrt arguments
+// (GDAL >= 2.1).
+static GDALDatasetH buildVRT(
+	const char *pszDest, int nSrcCount, GDALDatasetH *pahSrcDS, char **papszSrcDSNames,
+	char **papszArgv, int *pbUsageError
+) {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(2,1,0)
+	GDALDatasetH hDstDS;
+	GDALBuildVRTOptions *psOptions = GDALBuildVRTOptionsNew(papszArgv, NULL);
+	if(psOptions == NULL) {
+		*pbUsageError = TRUE;
+		return NULL;
+	}
+	hDstDS = GDALBuildVRT(pszDest, nSrcCount, pahSrcDS, (const char* const*)papszSrcDSNames, psOptions, pbUsageError);
+	GDALBuildVRTOptionsFree(psOptions);
+	return hDstDS;
+#else
+	CPLError(CE_Failure, CPLE_NotSupported, "GDALBuildVRT() requires GDAL >= 2.1");
+	return NULL;
+#endif
+}
+*/
+import "C"
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+type VRTResolution string
+
+const (
+	VRTResolution_Average VRTResolution = "average"
+	VRTResolution_Highest VRTResolution = "highest"
+	VRTResolution_Lowest  VRTResolution = "lowest"
+	VRTResolution_User    VRTResolution = "user" // use BuildVRTOptions.Resolution
+)
+
+// BuildVRTOptions are the options of BuildVRT (see gdalbuildvrt).
+//
+// Bands are the indices of the source bands in [0, Channels()), which are
+// used for every source. SourceBands are the band indices of each source
+// (nil means all bands), the k-th band of the i-th source in the VRT is the
+// band SourceBands[i][k] of the source. Bands and SourceBands are exclusive.
+type BuildVRTOptions struct {
+	Separate       bool          // -separate, put each source into a separate band
+	ResolutionType VRTResolution // -resolution
+	Resolution     [2]float64    // -tr xres yres
+	Bounds         *GeoRect      // -te
+	SrcNoData      *float64      // -srcnodata
+	VRTNoData      *float64      // -vrtnodata
+	Bands          []int         // -b band
+	SourceBands    [][]int       // band mapping of each source
+	ResampleType   ResampleType  // -r
+	AddAlpha       bool          // -addalpha
+	ExtArgs        []string      // other gdalbuildvrt arguments, such as "-allow_projection_difference"
+}
+
+// BuildVRT builds a mosaic (or a band stack with opt.Separate) VRT from the
+// source files.
+//
+// If filename is empty, the VRT is only kept in memory.
+func BuildVRT(filename string, srcFilenames []string, opt *BuildVRTOptions) (p *Dataset, err error) {
+	if opt != nil && opt.SourceBands != nil {
+		srcs := make([]*Dataset, 0, len(srcFilenames))
+		defer func() {
+			for _, src := range srcs {
+				src.Close()
+			}
+		}()
+		for _, name := range srcFilenames {
+			src, err := OpenDataset(name, GA_ReadOnly)
+			if err != nil {
+				return nil, fmt.Errorf("gdal: BuildVRT(%q) failed: %w", filename, err)
+			}
+			srcs = append(srcs, src)
+		}
+		return buildVRTWithSourceBands(filename, srcs, opt)
+	}
+
+	papszSrcDSNames := cStringArray(srcFilenames)
+	defer C.CSLDestroy(papszSrcDSNames)
+
+	return buildVRT(filename, len(srcFilenames), nil, papszSrcDSNames, opt)
+}
+
+// BuildVRTFromDatasets is same as BuildVRT, but use opened datasets as the
+// sources. The sources must be kept opened until the VRT is closed.
+func BuildVRTFromDatasets(filename string, srcs []*Dataset, opt *BuildVRTOptions) (p *Dataset, err error) {
+	if len(srcs) == 0 {
+		return nil, fmt.Errorf("gdal: BuildVRTFromDatasets(%q), no sources.", filename)
+	}
+	if opt != nil && opt.SourceBands != nil {
+		return buildVRTWithSourceBands(filename, srcs, opt)
+	}
+
+	unlock := lockDatasets(srcs)
+	defer unlock()
+
+	pahSrcDS := make([]C.GDALDatasetH, len(srcs))
+	for i, src := range srcs {
+		pahSrcDS[i] = src.poDataset
+	}
+	return buildVRT(filename, len(srcs), &pahSrcDS[0], nil, opt)
+}
+
+// lockDatasets locks the unique datasets of list by the order of address,
+// so the calls with the same datasets in different orders don't deadlock.
+func lockDatasets(list []*Dataset) (unlock func()) {
+	var unique []*Dataset
+	seen := make(map[*Dataset]bool)
+	for _, p := range list {
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return uintptr(unsafe.Pointer(unique[i])) < uintptr(unsafe.Pointer(unique[j]))
+	})
+	for _, p := range unique {
+		p.mu.Lock()
+	}
+	return func() {
+		for i := len(unique) - 1; i >= 0; i-- {
+			unique[i].mu.Unlock()
+		}
+	}
+}
+
+func buildVRT(filename string, nSrcCount int, pahSrcDS *C.GDALDatasetH, papszSrcDSNames **C.char, opt *BuildVRTOptions) (p *Dataset, err error) {
+	if opt == nil {
+		opt = new(BuildVRTOptions)
+	}
+	if nSrcCount == 0 {
+		return nil, fmt.Errorf("gdal: BuildVRT(%q), no sources.", filename)
+	}
+
+	args := opt.args()
+	papszArgv := cStringArray(args)
+	defer C.CSLDestroy(papszArgv)
+
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+
+	var (
+		poDataset   C.GDALDatasetH
+		bUsageError C.int
+	)
+	cplErr := cplCapture(func() {
+		poDataset = C.buildVRT(cname, C.int(nSrcCount), pahSrcDS, papszSrcDSNames, papszArgv, &bUsageError)
+	})
+	if bUsageError != 0 {
+		if poDataset != nil {
+			C.GDALClose(poDataset)
+		}
+		return nil, cplErrorf(cplErr, "gdal: BuildVRT(%q), bad options: %v", filename, args)
+	}
+	if poDataset == nil {
+		return nil, cplErrorf(cplErr, "gdal: BuildVRT(%q) failed", filename)
+	}
+	return newDatasetFromHandle(filename, poDataset, GA_Update), nil
+}
+
+// buildVRTWithSourceBands selects the bands of each source by a temporary
+// VRT (like gdal_translate -b), builds the VRT of them, and then rewrites
+// the sources of the VRT back to the original sources.
+func buildVRTWithSourceBands(filename string, srcs []*Dataset, opt *BuildVRTOptions) (p *Dataset, err error) {
+	if len(opt.SourceBands) != len(srcs) {
+		return nil, fmt.Errorf("gdal: BuildVRT(%q), SourceBands of %d sources, got %d.", filename, len(srcs), len(opt.SourceBands))
+	}
+	if len(opt.Bands) != 0 {
+		return nil, fmt.Errorf("gdal: BuildVRT(%q), Bands and SourceBands are exclusive.", filename)
+	}
+	// the sources are referenced by filename in the rewritten VRT
+	for i, src := range srcs {
+		if src.Filename == "" || src.Opt.DriverName == "MEM" {
+			return nil, fmt.Errorf("gdal: BuildVRT(%q), source %d has no filename, SourceBands requires the sources on disk or /vsimem.", filename, i)
+		}
+	}
+
+	// temporary VRT filename => source
+	type vrtSource struct {
+		filename string
+		bands    []int
+	}
+	tmpSources := make(map[string]vrtSource)
+	tmpSrcs := make([]*Dataset, len(srcs))
+	defer func() {
+		for i, src := range tmpSrcs {
+			if src != nil && src != srcs[i] {
+				src.Close()
+			}
+		}
+		for name := range tmpSources {
+			vsiUnlinkAll(name)
+		}
+	}()
+
+	for i, src := range srcs {
+		bands := opt.SourceBands[i]
+		if bands == nil {
+			tmpSrcs[i] = src
+			continue
+		}
+		tmpname := VSITempName() + ".vrt"
+		tmpSources[tmpname] = vrtSource{filename: src.Filename, bands: bands}
+
+		tmp, err := Translate(tmpname, src, &TranslateOptions{
+			Bands: bands,
+			Opt:   &Options{DriverName: "VRT"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gdal: BuildVRT(%q) failed: %w", filename, err)
+		}
+		tmp.Close()
+
+		if tmpSrcs[i], err = OpenDataset(tmpname, GA_ReadOnly); err != nil {
+			return nil, fmt.Errorf("gdal: BuildVRT(%q) failed: %w", filename, err)
+		}
+	}
+
+	tmpOpt := *opt
+	tmpOpt.SourceBands = nil
+	vrt, err := BuildVRTFromDatasets("", tmpSrcs, &tmpOpt)
+	if err != nil {
+		return nil, err
+	}
+	data := []byte(vrt.GetMetadata("xml:VRT")["xml:VRT"])
+	vrt.Close()
+
+	data, err = rewriteVRTSources(data, func(name string, band int) (string, int) {
+		if src, ok := tmpSources[name]; ok {
+			name = src.filename
+			if band >= 1 && band <= len(src.bands) {
+				band = src.bands[band-1] + 1
+			}
+		}
+		if filename != "" && !filepath.IsAbs(name) && !IsVSIPath(name) {
+			// the VRT is not in the current directory
+			if _, err := os.Stat(name); err == nil {
+				if abs, err := filepath.Abs(name); err == nil {
+					name = abs
+				}
+			}
+		}
+		return name, band
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gdal: BuildVRT(%q) failed: %w", filename, err)
+	}
+
+	if filename == "" {
+		return OpenDatasetFromBytes(data)
+	}
+	if err = ioutil.WriteFile(filename, data, 0666); err != nil {
+		return nil, err
+	}
+	return OpenDataset(filename, GA_Update)
+}
+
+// rewriteVRTSources rewrites the SourceFilename and SourceBand of the
+// sources in the VRT XML by fn, band is zero if it is not a band index.
+func rewriteVRTSources(data []byte, fn func(filename string, band int) (string, int)) ([]byte, error) {
+	var (
+		buf      bytes.Buffer
+		dec      = xml.NewDecoder(bytes.NewReader(data))
+		enc      = xml.NewEncoder(&buf)
+		filename string // SourceFilename of the current source
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || (start.Name.Local != "SourceFilename" && start.Name.Local != "SourceBand") {
+			if err := enc.EncodeToken(xml.CopyToken(tok)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var text string
+		if err := dec.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		switch start.Name.Local {
+		case "SourceFilename":
+			filename = text
+			if name, _ := fn(filename, 0); name != filename {
+				text = name
+				for i := range start.Attr {
+					if start.Attr[i].Name.Local == "relativeToVRT" {
+						start.Attr[i].Value = "0"
+					}
+				}
+			}
+		case "SourceBand":
+			// "mask,1" is the mask of band 1
+			prefix, s := "", text
+			if i := strings.LastIndexByte(text, ','); i >= 0 {
+				prefix, s = text[:i+1], text[i+1:]
+			}
+			if band, err := strconv.Atoi(s); err == nil {
+				_, band = fn(filename, band)
+				text = prefix + strconv.Itoa(band)
+			}
+		}
+		if err := enc.EncodeElement(text, start); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (opt *BuildVRTOptions) args() (args []string) {
+	if opt.Separate {
+		args = append(args, "-separate")
+	}
+	if opt.ResolutionType != "" {
+		args = append(args, "-resolution", string(opt.ResolutionType))
+	}
+	if opt.Resolution != [2]float64{} {
+		args = append(args, "-tr", ftoa(opt.Resolution[0]), ftoa(opt.Resolution[1]))
+	}
+	if opt.Bounds != nil {
+		args = append(args, "-te",
+			ftoa(opt.Bounds.MinX), ftoa(opt.Bounds.MinY),
+			ftoa(opt.Bounds.MaxX), ftoa(opt.Bounds.MaxY),
+		)
+	}
+	if opt.SrcNoData != nil {
+		args = append(args, "-srcnodata", ftoa(*opt.SrcNoData))
+	}
+	if opt.VRTNoData != nil {
+		args = append(args, "-vrtnodata", ftoa(*opt.VRTNoData))
+	}
+	for _, i := range opt.Bands {
+		args = append(args, "-b", strconv.Itoa(i+1))
+	}
+	if opt.ResampleType != ResampleType_Nil {
+		args = append(args, "-r", opt.ResampleType.warpName())
+	}
+	if opt.AddAlpha {
+		args = append(args, "-addalpha")
+	}
+	args = append(args, opt.ExtArgs...)
+	return
+}
+
+// VRTDataset is a builder of VRT dataset, the sources are added manually.
+// The zero Transform is not written to the VRT.
+//
+// Example:
+//
+//	vrt := gdal.NewVRTDataset(1024, 512)
+//	band := vrt.AddBand(reflect.Uint8)
+//	band.AddSource("left.tiff", 0, image.Rect(0, 0, 512, 512), image.Rect(0, 0, 512, 512))
+//	band.AddSource("right.tiff", 0, image.Rect(0, 0, 512, 512), image.Rect(512, 0, 1024, 512))
+//	p, err := vrt.Open()
+type VRTDataset struct {
+	Width      int
+	Height     int
+	Projection string
+	Transform  [6]float64
+	Bands      []*VRTBand
+}
+
+type VRTBand struct {
+	DataType    reflect.Kind
+	NoData      *float64
+	ColorInterp ColorInterp
+	Sources     []*VRTSource
+}
+
+// VRTSource is a source of VRTBand, Band is the index of the source band.
+//
+// If NoData is not nil, the source pixels with this value are transparent.
+type VRTSource struct {
+	Filename string
+	Band     int
+	SrcRect  image.Rectangle
+	DstRect  image.Rectangle
+	NoData   *float64
+}
+
+func NewVRTDataset(width, height int) *VRTDataset {
+	return &VRTDataset{
+		Width:  width,
+		Height: height,
+	}
+}
+
+func (p *VRTDataset) AddBand(dataType reflect.Kind) *VRTBand {
+	band := &VRTBand{DataType: dataType}
+	p.Bands = append(p.Bands, band)
+	return band
+}
+
+// AddSource maps the srcRect of the source band to the dstRect of the band.
+func (p *VRTBand) AddSource(filename string, band int, srcRect, dstRect image.Rectangle) *VRTSource {
+	src := &VRTSource{
+		Filename: filename,
+		Band:     band,
+		SrcRect:  srcRect,
+		DstRect:  dstRect,
+	}
+	p.Sources = append(p.Sources, src)
+	return src
+}
+
+// XML returns the VRT XML of the dataset.
+func (p *VRTDataset) XML() ([]byte, error) {
+	x := &xmlVRTDataset{
+		RasterXSize: p.Width,
+		RasterYSize: p.Height,
+		SRS:         p.Projection,
+	}
+	if p.Transform != [6]float64{} {
+		ss := make([]string, len(p.Transform))
+		for i, v := range p.Transform {
+			ss[i] = ftoa(v)
+		}
+		x.GeoTransform = strings.Join(ss, ", ")
+	}
+
+	for i, band := range p.Bands {
+		eType := gdalDataType(band.DataType)
+		if eType == C.GDT_Unknown {
+			return nil, fmt.Errorf("gdal: VRTDataset.XML, band %d: unsupported data type: %v", i, band.DataType)
+		}
+		xb := &xmlVRTBand{
+			DataType: C.GoString(C.GDALGetDataTypeName(eType)),
+			Band:     i + 1,
+		}
+		if band.NoData != nil {
+			xb.NoDataValue = ftoa(*band.NoData)
+		}
+		if band.ColorInterp != GCI_Undefined {
+			xb.ColorInterp = band.ColorInterp.Name()
+		}
+		for _, src := range band.Sources {
+			xs := &xmlVRTSource{
+				XMLName:        xml.Name{Local: "SimpleSource"},
+				SourceFilename: xmlVRTFilename{Filename: src.Filename},
+				SourceBand:     src.Band + 1,
+				SrcRect:        newXMLVRTRect(src.SrcRect),
+				DstRect:        newXMLVRTRect(src.DstRect),
+			}
+			if src.NoData != nil {
+				xs.XMLName.Local = "ComplexSource"
+				xs.NoData = ftoa(*src.NoData)
+			}
+			xb.Sources = append(xb.Sources, xs)
+		}
+		x.Bands = append(x.Bands, xb)
+	}
+
+	return xml.MarshalIndent(x, "", "  ")
+}
+
+// Save writes the VRT XML to filename.
+func (p *VRTDataset) Save(filename string) error {
+	data, err := p.XML()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0666)
+}
+
+// Open opens the VRT dataset from a /vsimem file, which is removed by
+// Dataset.Close.
+func (p *VRTDataset) Open() (*Dataset, error) {
+	data, err := p.XML()
+	if err != nil {
+		return nil, err
+	}
+	return OpenDatasetFromBytes(data)
+}
+
+type xmlVRTDataset struct {
+	XMLName      xml.Name      `xml:"VRTDataset"`
+	RasterXSize  int           `xml:"rasterXSize,attr"`
+	RasterYSize  int           `xml:"rasterYSize,attr"`
+	SRS          string        `xml:"SRS,omitempty"`
+	GeoTransform string        `xml:"GeoTransform,omitempty"`
+	Bands        []*xmlVRTBand `xml:"VRTRasterBand"`
+}
+
+type xmlVRTBand struct {
+	DataType    string          `xml:"dataType,attr"`
+	Band        int             `xml:"band,attr"`
+	NoDataValue string          `xml:"NoDataValue,omitempty"`
+	ColorInterp string          `xml:"ColorInterp,omitempty"`
+	Sources     []*xmlVRTSource // SimpleSource or ComplexSource
+}
+
+type xmlVRTSource struct {
+	XMLName        xml.Name
+	SourceFilename xmlVRTFilename `xml:"SourceFilename"`
+	SourceBand     int            `xml:"SourceBand"`
+	SrcRect        *xmlVRTRect    `xml:"SrcRect,omitempty"`
+	DstRect        *xmlVRTRect    `xml:"DstRect,omitempty"`
+	NoData         string         `xml:"NODATA,omitempty"`
+}
+
+type xmlVRTFilename struct {
+	RelativeToVRT int    `xml:"relativeToVRT,attr"`
+	Filename      string `xml:",chardata"`
+}
+
+type xmlVRTRect struct {
+	XOff  int `xml:"xOff,attr"`
+	YOff  int `xml:"yOff,attr"`
+	XSize int `xml:"xSize,attr"`
+	YSize int `xml:"ySize,attr"`
+}
+
+func newXMLVRTRect(r image.Rectangle) *xmlVRTRect {
+	if r.Empty() {
+		return nil
+	}
+	return &xmlVRTRect{
+		XOff:  r.Min.X,
+		YOff:  r.Min.Y,
+		XSize: r.Dx(),
+		YSize: r.Dy(),
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVRTDataset_Open(t *testing.T) {
+	const filename = "./testdata/video-001.tiff"
+
+	m, err := LoadImage(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := m.Bounds()
+
+	vrt := NewVRTDataset(b.Dx()*2, b.Dy())
+	for i := 0; i < m.XChannels; i++ {
+		band := vrt.AddBand(reflect.Uint8)
+		band.AddSource(filename, i, b, b)
+		band.AddSource(filename, i, b, b.Add(image.Pt(b.Dx(), 0)))
+	}
+
+	f, err := vrt.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if !strings.HasPrefix(f.Filename, VSIPrefix_Mem) {
+		t.Fatalf("expect /vsimem file, got = %q", f.Filename)
+	}
+
+	if f.Width() != b.Dx()*2 || f.Height() != b.Dy() || f.Channels() != m.XChannels {
+		t.Fatalf("bad vrt: %dx%dx%d", f.Width(), f.Height(), f.Channels())
+	}
+
+	right, err := f.Read(b.Add(image.Pt(b.Dx(), 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(right.(*MemPImage).XPix, m.XPix) {
+		t.Fatal("right half not equal")
+	}
+}
+
+func TestBuildVRT_separate(t *testing.T) {
+	filenames := []string{
+		"./testdata/video-001-gray.tiff",
+		"./testdata/video-001-gray.tiff",
+	}
+	f, err := BuildVRT("", filenames, &BuildVRTOptions{Separate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Channels() != len(filenames) {
+		t.Fatalf("expect = %d, got = %d", len(filenames), f.Channels())
+	}
+}
+
+func TestBuildVRT_sourceBands(t *testing.T) {
+	const filename = "./testdata/video-001.tiff"
+
+	m, err := LoadImage(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// blue of the first source, red of the second source
+	f, err := BuildVRT("", []string{filename, filename}, &BuildVRTOptions{
+		Separate:    true,
+		SourceBands: [][]int{{2}, {0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Channels() != 2 {
+		t.Fatalf("channels: expect = 2, got = %d", f.Channels())
+	}
+	got, err := f.Read(m.Bounds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix := got.(*MemPImage).XPix
+	for i, n := 0, m.Bounds().Dx()*m.Bounds().Dy(); i < n; i++ {
+		if pix[i*2+0] != m.XPix[i*3+2] || pix[i*2+1] != m.XPix[i*3+0] {
+			t.Fatalf("pixel %d: expect = (%d, %d), got = (%d, %d)", i,
+				m.XPix[i*3+2], m.XPix[i*3+0], pix[i*2+0], pix[i*2+1],
+			)
+		}
+	}
+
+	if _, err := BuildVRT("", []string{filename}, &BuildVRTOptions{
+		SourceBands: [][]int{{0}, {1}},
+	}); err == nil {
+		t.Fatal("expect error for mismatched SourceBands")
+	}
+}
+
+func TestBuildVRTFromDatasets_memSourceBands(t *testing.T) {
+	src, err := CreateDataset("", 4, 4, 3, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	if _, err := BuildVRTFromDatasets("", []*Dataset{src}, &BuildVRTOptions{
+		SourceBands: [][]int{{0}},
+	}); err == nil {
+		t.Fatal("expect error for MEM source")
+	}
+
+	// the MEM source without SourceBands
+	f, err := BuildVRTFromDatasets("", []*Dataset{src}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Channels() != 3 {
+		t.Fatalf("channels: expect = 3, got = %d", f.Channels())
+	}
+}
+
+func TestBuildVRTFromDatasets_lockOrder(t *testing.T) {
+	a, err := CreateDataset("", 4, 4, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := CreateDataset("", 4, 4, 1, reflect.Uint8, &Options{DriverName: "MEM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// (a, b) and (b, a) must not deadlock
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(srcs []*Dataset) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if f, err := BuildVRTFromDatasets("", srcs, &BuildVRTOptions{Separate: true}); err == nil {
+					f.Close()
+				}
+			}
+		}([][]*Dataset{{a, b}, {b, a}}[i])
+	}
+	wg.Wait()
+}
+
+func TestRewriteVRTSources(t *testing.T) {
+	const data = `<VRTDataset rasterXSize="1" rasterYSize="1">
+  <VRTRasterBand dataType="Byte" band="1">
+    <SimpleSource>
+      <SourceFilename relativeToVRT="1">tmp.vrt</SourceFilename>
+      <SourceBand>1</SourceBand>
+    </SimpleSource>
+    <ComplexSource>
+      <SourceFilename relativeToVRT="0">b.tif</SourceFilename>
+      <SourceBand>mask,1</SourceBand>
+    </ComplexSource>
+  </VRTRasterBand>
+</VRTDataset>`
+
+	got, err := rewriteVRTSources([]byte(data), func(name string, band int) (string, int) {
+		if name == "tmp.vrt" {
+			return "a.tif", band + 2
+		}
+		return name, band
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`<SourceFilename relativeToVRT="0">a.tif</SourceFilename>`,
+		`<SourceBand>3</SourceBand>`,
+		`<SourceFilename relativeToVRT="0">b.tif</SourceFilename>`,
+		`<SourceBand>mask,1</SourceBand>`,
+	} {
+		if !strings.Contains(string(got), s) {
+			t.Fatalf("expect %q in:\n%s", s, got)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+/*
+#include <cpl_vsi.h>
+#include <cpl_string.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static int vsiStat(const char* pszFilename, long long* size, int* mode, long long* mtime, int* isDir) {
+	VSIStatBufL sStat;
+	if(VSIStatL(pszFilename, &sStat) != 0) {
+		return -1;
+	}
+	*size = (long long)sStat.st_size;
+	*mode = (int)sStat.st_mode;
+	*mtime = (long long)sStat.st_mtime;
+	*isDir = VSI_ISDIR(sStat.st_mode)? 1: 0;
+	return 0;
+}
+*/
+import "C"
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// The prefixes of the GDAL virtual file systems.
+const (
+	VSIPrefix_Mem  = "/vsimem/"  // in memory files
+	VSIPrefix_Zip  = "/vsizip/"  // files in zip archives
+	VSIPrefix_Gzip = "/vsigzip/" // gzip compressed file
+	VSIPrefix_Tar  = "/vsitar/"  // files in tar (or .tar.gz) archives
+	VSIPrefix_Curl = "/vsicurl/" // HTTP/HTTPS/FTP files
+)
+
+var vsiPrefixList = []string{
+	VSIPrefix_Mem,
+	VSIPrefix_Zip,
+	VSIPrefix_Gzip,
+	VSIPrefix_Tar,
+	VSIPrefix_Curl,
+}
+
+// VSIZipPath returns the /vsizip/ path of the member in the zip archive,
+// such as "/vsizip/data.zip/a/b.tif". If member is empty, the path is the
+// root of the archive.
+//
+// The archive may be a VSI path, such as VSICurlPath("http://host/data.zip").
+func VSIZipPath(archive string, member ...string) string {
+	return vsiArchivePath(VSIPrefix_Zip, archive, member)
+}
+
+// VSITarPath is same as VSIZipPath for tar (or .tar.gz/.tgz) archives.
+func VSITarPath(archive string, member ...string) string {
+	return vsiArchivePath(VSIPrefix_Tar, archive, member)
+}
+
+// VSIGzipPath returns the /vsigzip/ path of the gzip compressed file.
+func VSIGzipPath(filename string) string {
+	return VSIPrefix_Gzip + filename
+}
+
+// VSICurlPath returns the /vsicurl/ path of the HTTP, HTTPS or FTP url.
+func VSICurlPath(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", fmt.Errorf("gdal: VSICurlPath(%q) failed: %w", rawurl, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "ftp":
+	default:
+		return "", fmt.Errorf("gdal: VSICurlPath(%q), unsupported scheme: %q", rawurl, u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("gdal: VSICurlPath(%q), missing host.", rawurl)
+	}
+	return VSIPrefix_Curl + rawurl, nil
+}
+
+func vsiArchivePath(prefix, archive string, member []string) string {
+	s := prefix + archive
+	if name := strings.Trim(path.Join(member...), "/"); name != "" && name != "." {
+		s += "/" + name
+	}
+	return s
+}
+
+// IsVSIPath reports whether filename is a path of the virtual file systems
+// supported by this package.
+func IsVSIPath(filename string) bool {
+	for _, prefix := range vsiPrefixList {
+		if strings.HasPrefix(filename, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateVSIPath checks the syntax of the VSI path (include the chained
+// paths, such as "/vsizip//vsicurl/http://host/data.zip/a.tif"), the files
+// are not accessed.
+func ValidateVSIPath(filename string) error {
+	for s := filename; ; {
+		var prefix string
+		for _, v := range vsiPrefixList {
+			if strings.HasPrefix(s, v) {
+				prefix = v
+				break
+			}
+		}
+		if prefix == "" {
+			if s == filename {
+				return fmt.Errorf("gdal: ValidateVSIPath(%q), unknown prefix.", filename)
+			}
+			return nil
+		}
+
+		s = s[len(prefix):]
+		if s == "" || s == "/" {
+			return fmt.Errorf("gdal: ValidateVSIPath(%q), missing file name after %q.", filename, prefix)
+		}
+		if prefix == VSIPrefix_Curl {
+			if _, err := VSICurlPath(s); err != nil {
+				return fmt.Errorf("gdal: ValidateVSIPath(%q) failed: %w", filename, err)
+			}
+			return nil
+		}
+		// the chained path, such as "/vsizip//vsicurl/..."
+		if strings.HasPrefix(s, "/vsi") {
+			continue
+		}
+		if strings.HasPrefix(s, "vsi") && IsVSIPath("/"+s) {
+			s = "/" + s
+			continue
+		}
+		return nil
+	}
+}
+
+// VSIStat returns the os.FileInfo of the (virtual) file.
+func VSIStat(filename string) (os.FileInfo, error) {
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+
+	var size, mtime C.longlong
+	var mode, isDir C.int
+	if C.vsiStat(cname, &size, &mode, &mtime, &isDir) != 0 {
+		return nil, &os.PathError{Op: "VSIStat", Path: filename, Err: os.ErrNotExist}
+	}
+
+	fi := &vsiFileInfo{
+		name:    path.Base(strings.TrimRight(filename, "/")),
+		size:    int64(size),
+		mode:    os.FileMode(mode) & os.ModePerm,
+		modTime: time.Unix(int64(mtime), 0),
+	}
+	if isDir != 0 {
+		fi.mode |= os.ModeDir
+	}
+	return fi, nil
+}
+
+type vsiFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (p *vsiFileInfo) Name() string       { return p.name }
+func (p *vsiFileInfo) Size() int64        { return p.size }
+func (p *vsiFileInfo) Mode() os.FileMode  { return p.mode }
+func (p *vsiFileInfo) ModTime() time.Time { return p.modTime }
+func (p *vsiFileInfo) IsDir() bool        { return p.mode.IsDir() }
+func (p *vsiFileInfo) Sys() interface{}   { return nil }
+
+// VSIReadDir returns the file names in the (virtual) directory, such as the
+// root of a zip archive. The "." and ".." are not included.
+func VSIReadDir(dirname string) ([]string, error) {
+	fi, err := VSIStat(dirname)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("gdal: VSIReadDir(%q), not a directory.", dirname)
+	}
+
+	cname := C.CString(dirname)
+	defer C.free(unsafe.Pointer(cname))
+
+	papszFiles := C.VSIReadDir(cname)
+	defer C.CSLDestroy(papszFiles)
+
+	var names []string
+	for _, s := range goStringList(papszFiles) {
+		if s != "." && s != ".." {
+			names = append(names, s)
+		}
+	}
+	return names, nil
+}
+
+// VSIFile is a (virtual) file opened for reading.
+type VSIFile struct {
+	Filename string
+	fp       *C.VSILFILE
+}
+
+// VSIOpen opens the (virtual) file for reading.
+func VSIOpen(filename string) (*VSIFile, error) {
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+
+	cMode := C.CString("rb")
+	defer C.free(unsafe.Pointer(cMode))
+
+	var fp *C.VSILFILE
+	e := cplCapture(func() { fp = C.VSIFOpenL(cname, cMode) })
+	if fp == nil {
+		return nil, cplErrorf(e, "gdal: VSIOpen(%q) failed", filename)
+	}
+	return &VSIFile{Filename: filename, fp: fp}, nil
+}
+
+func (f *VSIFile) Read(b []byte) (n int, err error) {
+	if f.fp == nil {
+		return 0, fmt.Errorf("gdal: VSIFile(%q).Read, file closed.", f.Filename)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	n = int(C.VSIFReadL(unsafe.Pointer(&b[0]), 1, C.size_t(len(b)), f.fp))
+	if n < len(b) {
+		if C.VSIFEofL(f.fp) != 0 {
+			err = io.EOF
+		} else if n == 0 {
+			err = fmt.Errorf("gdal: VSIFile(%q).Read failed.", f.Filename)
+		}
+	}
+	return
+}
+
+func (f *VSIFile) Seek(offset int64, whence int) (int64, error) {
+	if f.fp == nil {
+		return 0, fmt.Errorf("gdal: VSIFile(%q).Seek, file closed.", f.Filename)
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(C.VSIFTellL(f.fp))
+	case io.SeekEnd:
+		if C.VSIFSeekL(f.fp, 0, C.SEEK_END) != 0 {
+			return 0, fmt.Errorf("gdal: VSIFile(%q).Seek failed.", f.Filename)
+		}
+		offset += int64(C.VSIFTellL(f.fp))
+	default:
+		return 0, fmt.Errorf("gdal: VSIFile(%q).Seek, invalid whence: %d", f.Filename, whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("gdal: VSIFile(%q).Seek, negative position: %d", f.Filename, offset)
+	}
+	if C.VSIFSeekL(f.fp, C.vsi_l_offset(offset), C.SEEK_SET) != 0 {
+		return 0, fmt.Errorf("gdal: VSIFile(%q).Seek failed.", f.Filename)
+	}
+	return offset, nil
+}
+
+func (f *VSIFile) Close() error {
+	if f.fp == nil {
+		return nil
+	}
+	rv := C.VSIFCloseL(f.fp)
+	f.fp = nil
+	if rv != 0 {
+		return fmt.Errorf("gdal: VSIFile(%q).Close failed.", f.Filename)
+	}
+	return nil
+}
+
+// VSIUnlink removes the (virtual) file, such as a /vsimem file.
+func VSIUnlink(filename string) error {
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+
+	if C.VSIUnlink(cname) != 0 {
+		return fmt.Errorf("gdal: VSIUnlink(%q) failed.", filename)
+	}
+	return nil
+}
+
+// VSIReadFile reads the whole (virtual) file, such as a member of a zip
+// archive or a remote file.
+func VSIReadFile(filename string) ([]byte, error) {
+	f, err := VSIOpen(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ioutil.ReadAll(f)
+}
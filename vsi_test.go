@@ -0,0 +1,199 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestVSIPath(t *testing.T) {
+	curl, err := VSICurlPath("http://example.com/data.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct{ got, expect string }{
+		{VSIZipPath("data.zip"), "/vsizip/data.zip"},
+		{VSIZipPath("data.zip", "a", "b.tif"), "/vsizip/data.zip/a/b.tif"},
+		{VSIZipPath("/tmp/data.zip", "/b.tif"), "/vsizip//tmp/data.zip/b.tif"},
+		{VSITarPath("data.tar.gz", "b.tif"), "/vsitar/data.tar.gz/b.tif"},
+		{VSIGzipPath("b.tif.gz"), "/vsigzip/b.tif.gz"},
+		{curl, "/vsicurl/http://example.com/data.zip"},
+		{VSIZipPath(curl, "b.tif"), "/vsizip//vsicurl/http://example.com/data.zip/b.tif"},
+	} {
+		if v.got != v.expect {
+			t.Fatalf("expect = %q, got = %q", v.expect, v.got)
+		}
+		if err := ValidateVSIPath(v.got); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, s := range []string{"file:///tmp/a.tif", "example.com/a.tif", "http:///a.tif"} {
+		if _, err := VSICurlPath(s); err == nil {
+			t.Fatalf("VSICurlPath(%q): expect error", s)
+		}
+	}
+	for _, s := range []string{"data.zip", "/vsizip/", "/vsicurl/file:///a.tif"} {
+		if err := ValidateVSIPath(s); err == nil {
+			t.Fatalf("ValidateVSIPath(%q): expect error", s)
+		}
+	}
+}
+
+func TestVSIZip(t *testing.T) {
+	data := tbLoadData(t, "video-001.tiff")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("images/video-001.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	const filename = "z_test_vsi.zip"
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(filename)
+
+	names, err := VSIReadDir(VSIZipPath(filename, "images"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "video-001.tiff" {
+		t.Fatalf("names: %v", names)
+	}
+
+	member := VSIZipPath(filename, "images", "video-001.tiff")
+	fi, err := VSIStat(member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.IsDir() || fi.Size() != int64(len(data)) {
+		t.Fatalf("stat: dir = %v, size = %d", fi.IsDir(), fi.Size())
+	}
+
+	got, err := VSIReadFile(member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("data not equal")
+	}
+
+	f, err := OpenDataset(member, GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Width() != 150 || f.Height() != 103 {
+		t.Fatalf("size: %dx%d", f.Width(), f.Height())
+	}
+
+	if _, err := VSIStat(VSIZipPath(filename, "not-found.tiff")); !os.IsNotExist(err) {
+		t.Fatalf("expect not exist, got %v", err)
+	}
+}
+
+func TestVSIGzipTar(t *testing.T) {
+	data := tbLoadData(t, "video-001.tiff")
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "video-001.tiff", Mode: 0644, Size: int64(len(data))})
+	tw.Write(data)
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	const filename = "z_test_vsi.tar.gz"
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(filename)
+
+	for _, s := range []string{
+		VSITarPath(filename, "video-001.tiff"),
+		VSITarPath(VSIGzipPath(filename), "video-001.tiff"),
+	} {
+		f, err := OpenDataset(s, GA_ReadOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Width() != 150 || f.Height() != 103 {
+			t.Fatalf("%s: size: %dx%d", s, f.Width(), f.Height())
+		}
+		f.Close()
+	}
+}
+
+func TestVSICurl(t *testing.T) {
+	ts := httptest.NewServer(http.FileServer(http.Dir("./testdata")))
+	defer ts.Close()
+
+	filename, err := VSICurlPath(ts.URL + "/video-001.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := VSIReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, tbLoadData(t, "video-001.tiff")) {
+		t.Fatal("data not equal")
+	}
+
+	f, err := OpenDataset(filename, GA_ReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Width() != 150 || f.Height() != 103 {
+		t.Fatalf("size: %dx%d", f.Width(), f.Height())
+	}
+}
+
+func TestVSIFile_Seek(t *testing.T) {
+	data := tbLoadData(t, "video-001.png")
+
+	f, err := VSIOpen("./testdata/video-001.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	n, err := f.Seek(-4, io.SeekEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)-4) {
+		t.Fatalf("Seek: %d", n)
+	}
+	tail, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tail, data[len(data)-4:]) {
+		t.Fatalf("tail: %x", tail)
+	}
+}
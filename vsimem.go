@@ -0,0 +1,154 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+//#include <gdal.h>
+//#include <cpl_vsi.h>
+//#include <stdlib.h>
+import "C"
+import (
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+	"unsafe"
+)
+
+var (
+	vsiTempMutex sync.Mutex
+	vsiTempId    int64
+)
+
+// VSITempName returns a unique file name in /vsimem.
+func VSITempName() string {
+	vsiTempMutex.Lock()
+	defer vsiTempMutex.Unlock()
+
+	vsiTempId++
+	return fmt.Sprintf("/vsimem/gdal.VSITempName.%08d.tmp", vsiTempId)
+}
+
+// OpenDatasetFromBytes opens the dataset from the encoded file data (such
+// as PNG or GeoTIFF) in read only mode.
+//
+// The data is copied to a /vsimem file, which is removed by Dataset.Close.
+func OpenDatasetFromBytes(data []byte) (p *Dataset, err error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("gdal: OpenDatasetFromBytes, empty data.")
+	}
+
+	filename := VSITempName()
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+
+	// the buffer is owned (and freed) by GDAL
+	pabyData := C.CBytes(data)
+	fp := C.VSIFileFromMemBuffer(cname, (*C.GByte)(pabyData), C.vsi_l_offset(len(data)), C.TRUE)
+	if fp == nil {
+		C.free(pabyData)
+		return nil, fmt.Errorf("gdal: OpenDatasetFromBytes, VSIFileFromMemBuffer(%q) failed.", filename)
+	}
+	C.VSIFCloseL(fp)
+
+	if p, err = OpenDataset(filename, GA_ReadOnly); err != nil {
+		C.VSIUnlink(cname)
+		return nil, err
+	}
+	p.vsiFilename = filename
+	return p, nil
+}
+
+// OpenDatasetFromReader is same as OpenDatasetFromBytes with the data of r.
+func OpenDatasetFromReader(r io.Reader) (p *Dataset, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return OpenDatasetFromBytes(data)
+}
+
+// SaveToBytes encodes the image m with the driver (such as "PNG" or "GTiff").
+//
+// The Projection, Transform, NoData and ExtOptions (creation options) of
+// opt are used, opt.DriverName is ignored.
+func SaveToBytes(m image.Image, driverName string, opt *Options) (data []byte, err error) {
+	src, err := newMemDatasetFrom(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var dstOpt Options
+	if opt != nil {
+		dstOpt = *opt
+	}
+	dstOpt.DriverName = driverName
+
+	filename := VSITempName()
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+	defer vsiUnlinkAll(filename)
+
+	dst, err := CreateDatasetCopy(filename, src, &dstOpt)
+	if err != nil {
+		return nil, err
+	}
+	dst.Close()
+
+	var nLength C.vsi_l_offset
+	pabyData := C.VSIGetMemFileBuffer(cname, &nLength, C.TRUE)
+	if pabyData == nil {
+		return nil, fmt.Errorf("gdal: SaveToBytes(%q), VSIGetMemFileBuffer(%q) failed.", driverName, filename)
+	}
+	defer C.VSIFree(unsafe.Pointer(pabyData))
+
+	// C.GoBytes takes a C.int length, which overflows at 2 GiB
+	if uint64(nLength) > uint64(math.MaxInt) {
+		return nil, fmt.Errorf("gdal: SaveToBytes(%q), too large: %d bytes.", driverName, uint64(nLength))
+	}
+	data = make([]byte, int(nLength))
+	copy(data, unsafe.Slice((*byte)(unsafe.Pointer(pabyData)), int(nLength)))
+	return data, nil
+}
+
+// newMemDatasetFrom creates a MEM dataset with the pixels of m, the palette
+// of *image.Paletted is kept as the color table.
+//
+// The Projection, Transform and NoData of opt are used, the NoData of m
+// is used if opt.NoData is nil.
+func newMemDatasetFrom(m image.Image, opt *Options) (*Dataset, error) {
+	p, pal := memPImageToWrite(m)
+
+	var memOpt Options
+	if opt != nil {
+		memOpt = *opt
+	}
+	memOpt.DriverName = "MEM"
+	memOpt.ExtOptions = nil
+	if memOpt.NoData == nil {
+		memOpt.NoData = p.XNoData
+	}
+
+	src, err := CreateDataset("", p.XRect.Dx(), p.XRect.Dy(), p.XChannels, p.XDataType, &memOpt)
+	if err != nil {
+		return nil, err
+	}
+	if err = src.writeMemPImage(p, pal); err != nil {
+		src.Close()
+		return nil, err
+	}
+	return src, nil
+}
+
+// vsiUnlinkAll removes the /vsimem file and its side car files.
+func vsiUnlinkAll(filename string) {
+	for _, s := range []string{filename, filename + ".aux.xml", filename + ".ovr"} {
+		cname := C.CString(s)
+		C.VSIUnlink(cname)
+		C.free(unsafe.Pointer(cname))
+	}
+}
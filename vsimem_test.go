@@ -0,0 +1,81 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOpenDatasetFromBytes(t *testing.T) {
+	const filename = "./testdata/video-001.png"
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := LoadImage(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenDatasetFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := f.Read(expect.Bounds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	vsiFilename := f.vsiFilename
+	f.Close()
+
+	if !bytes.Equal(m.(*MemPImage).XPix, expect.XPix) {
+		t.Fatal("pixels not equal")
+	}
+	if _, err := OpenDataset(vsiFilename, GA_ReadOnly); err == nil {
+		t.Fatalf("expect %q removed", vsiFilename)
+	}
+}
+
+func TestOpenDatasetFromReader(t *testing.T) {
+	r, err := os.Open("./testdata/video-001.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	f, err := OpenDatasetFromReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Opt.DriverName != "GTiff" {
+		t.Fatalf("expect = %q, got = %q", "GTiff", f.Opt.DriverName)
+	}
+}
+
+func TestSaveToBytes(t *testing.T) {
+	m, err := LoadImage("./testdata/video-001.tiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := SaveToBytes(m, "PNG", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m2.Bounds() != m.Bounds() {
+		t.Fatalf("expect = %v, got = %v", m.Bounds(), m2.Bounds())
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+/*
+#include <gdal.h>
+#include <gdal_version.h>
+#include <gdalwarper.h>
+#include <cpl_string.h>
+#include <stdlib.h>
+
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(2,1,0)
+#include <gdal_utils.h>
+#endif
+
+// warp is GDALWarp of gdal_utils.h with the gdalwarp arguments (GDAL >= 2.1).
+static GDALDatasetH warp(const char *pszDest, GDALDatasetH hSrcDS, char **papszArgv, int *pbUsageError) {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(2,1,0)
+	GDALDatasetH hDstDS;
+	GDALWarpAppOptions *psOptions = GDALWarpAppOptionsNew(papszArgv, NULL);
+	if(psOptions == NULL) {
+		*pbUsageError = TRUE;
+		return NULL;
+	}
+	hDstDS = GDALWarp(pszDest, NULL, 1, &hSrcDS, psOptions, pbUsageError);
+	GDALWarpAppOptionsFree(psOptions);
+	return hDstDS;
+#else
+	CPLError(CE_Failure, CPLE_NotSupported, "GDALWarp() requires GDAL >= 2.1");
+	return NULL;
+#endif
+}
+
+// GRA_Max/Min/Med/Q1/Q3 are added in GDAL 2.0, GRA_Sum in 3.1 and GRA_RMS in 3.3.
+static int graMax() {
+#if GDAL_VERSION_MAJOR >= 2
+	return GRA_Max;
+#else
+	return -1;
+#endif
+}
+static int graMin() {
+#if GDAL_VERSION_MAJOR >= 2
+	return GRA_Min;
+#else
+	return -1;
+#endif
+}
+static int graMed() {
+#if GDAL_VERSION_MAJOR >= 2
+	return GRA_Med;
+#else
+	return -1;
+#endif
+}
+static int graQ1() {
+#if GDAL_VERSION_MAJOR >= 2
+	return GRA_Q1;
+#else
+	return -1;
+#endif
+}
+static int graQ3() {
+#if GDAL_VERSION_MAJOR >= 2
+	return GRA_Q3;
+#else
+	return -1;
+#endif
+}
+static int graSum() {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(3,1,0)
+	return GRA_Sum;
+#else
+	return -1;
+#endif
+}
+static int graRMS() {
+#if defined(GDAL_COMPUTE_VERSION) && GDAL_VERSION_NUM >= GDAL_COMPUTE_VERSION(3,3,0)
+	return GRA_RMS;
+#else
+	return -1;
+#endif
+}
+*/
+import "C"
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// WarpOptions are the options of Warp (see gdalwarp).
+//
+// Resolution (-tr) and Size (-ts) are exclusive, zero means computed by GDAL.
+// If Opt is nil or Opt.DriverName is empty, the output driver is guessed
+// from the filename (or "MEM" for empty filename).
+type WarpOptions struct {
+	DstSRS       *SpatialReference // -t_srs
+	SrcSRS       *SpatialReference // -s_srs, override the source projection
+	Resolution   [2]float64        // -tr xres yres
+	Width        int               // -ts width height
+	Height       int               // -ts width height
+	Bounds       *GeoRect          // -te, in the target SRS
+	ResampleType ResampleType      // -r
+	SrcNoData    *float64          // -srcnodata
+	DstNoData    *float64          // -dstnodata
+	DstAlpha     bool              // -dstalpha
+	Opt          *Options          // -of, -co
+	ExtArgs      []string          // other gdalwarp arguments, such as "-wm", "512"
+}
+
+// Warp reprojects the src dataset to filename.
+//
+// If filename is empty, an in-memory dataset is returned.
+func Warp(filename string, src *Dataset, opt *WarpOptions) (p *Dataset, err error) {
+	if opt == nil {
+		opt = new(WarpOptions)
+	}
+	args, err := opt.args(filename)
+	if err != nil {
+		return nil, fmt.Errorf("gdal: Warp(%q) failed: %w", filename, err)
+	}
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	papszArgv := cStringArray(args)
+	defer C.CSLDestroy(papszArgv)
+
+	cname := C.CString(filename)
+	defer C.free(unsafe.Pointer(cname))
+
+	var (
+		poDataset   C.GDALDatasetH
+		bUsageError C.int
+	)
+	cplErr := cplCapture(func() {
+		poDataset = C.warp(cname, src.poDataset, papszArgv, &bUsageError)
+	})
+	if bUsageError != 0 {
+		if poDataset != nil {
+			C.GDALClose(poDataset)
+		}
+		return nil, cplErrorf(cplErr, "gdal: Warp(%q), bad options: %v", filename, args)
+	}
+	if poDataset == nil {
+		return nil, cplErrorf(cplErr, "gdal: Warp(%q) failed", filename)
+	}
+	return newDatasetFromHandle(filename, poDataset, GA_Update), nil
+}
+
+// AutoCreateWarpedVRT creates a virtual warped dataset of p in the dstSRS.
+//
+// If dstSRS is nil, the projection of p is used. The dataset p must be
+// kept opened until the returned dataset is closed.
+func (p *Dataset) AutoCreateWarpedVRT(dstSRS *SpatialReference, resampleType ResampleType, maxError float64) (*Dataset, error) {
+	var cDstWkt *C.char
+	if dstSRS != nil {
+		wkt, err := dstSRS.ExportToWkt()
+		if err != nil {
+			return nil, err
+		}
+		cDstWkt = C.CString(wkt)
+		defer C.free(unsafe.Pointer(cDstWkt))
+	}
+
+	eResampleAlg, ok := resampleType.warpResampleAlg()
+	if !ok {
+		return nil, fmt.Errorf("gdal: Dataset(%q).AutoCreateWarpedVRT, unsupported resample type: %s", p.Filename, resampleType.Name())
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var poDataset C.GDALDatasetH
+	cplErr := cplCapture(func() {
+		poDataset = C.GDALAutoCreateWarpedVRT(p.poDataset, nil, cDstWkt,
+			eResampleAlg, C.double(maxError), nil,
+		)
+	})
+	if poDataset == nil {
+		return nil, cplErrorf(cplErr, "gdal: Dataset(%q).AutoCreateWarpedVRT failed", p.Filename)
+	}
+	return newDatasetFromHandle("", poDataset, GA_ReadOnly), nil
+}
+
+func (opt *WarpOptions) args(filename string) (args []string, err error) {
+	if opt.DstSRS != nil {
+		wkt, err := opt.DstSRS.ExportToWkt()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-t_srs", wkt)
+	}
+	if opt.SrcSRS != nil {
+		wkt, err := opt.SrcSRS.ExportToWkt()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-s_srs", wkt)
+	}
+	if opt.Resolution != [2]float64{} && (opt.Width != 0 || opt.Height != 0) {
+		return nil, fmt.Errorf("gdal: WarpOptions, Resolution and Width/Height are exclusive.")
+	}
+	if opt.Resolution != [2]float64{} {
+		args = append(args, "-tr", ftoa(opt.Resolution[0]), ftoa(opt.Resolution[1]))
+	}
+	if opt.Width != 0 || opt.Height != 0 {
+		args = append(args, "-ts", strconv.Itoa(opt.Width), strconv.Itoa(opt.Height))
+	}
+	if opt.Bounds != nil {
+		args = append(args, "-te",
+			ftoa(opt.Bounds.MinX), ftoa(opt.Bounds.MinY),
+			ftoa(opt.Bounds.MaxX), ftoa(opt.Bounds.MaxY),
+		)
+	}
+	if opt.ResampleType != ResampleType_Nil {
+		if _, ok := opt.ResampleType.warpResampleAlg(); !ok {
+			return nil, fmt.Errorf("gdal: WarpOptions, unsupported resample type: %s", opt.ResampleType.Name())
+		}
+		args = append(args, "-r", opt.ResampleType.warpName())
+	}
+	if opt.SrcNoData != nil {
+		args = append(args, "-srcnodata", ftoa(*opt.SrcNoData))
+	}
+	if opt.DstNoData != nil {
+		args = append(args, "-dstnodata", ftoa(*opt.DstNoData))
+	}
+	if opt.DstAlpha {
+		args = append(args, "-dstalpha")
+	}
+	args = append(args, outputArgs(filename, opt.Opt)...)
+	args = append(args, opt.ExtArgs...)
+	return args, nil
+}
+
+// outputArgs returns the -of and -co arguments of GDAL utilities.
+func outputArgs(filename string, opt *Options) (args []string) {
+	driverName := ""
+	if opt != nil {
+		driverName = opt.DriverName
+	}
+	if driverName == "" {
+		if filename == "" {
+			driverName = "MEM"
+		} else {
+			driverName = getDefaultDriverNameByFilenameExt(filename)
+		}
+	}
+	if driverName != "" {
+		args = append(args, "-of", driverName)
+	}
+
+	if opt != nil && len(opt.ExtOptions) != 0 {
+		keys := make([]string, 0, len(opt.ExtOptions))
+		for k := range opt.ExtOptions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			args = append(args, "-co", k+"="+opt.ExtOptions[k])
+		}
+	}
+	return
+}
+
+// warpName returns the resampling name of gdalwarp.
+func (p ResampleType) warpName() string {
+	switch p {
+	case ResampleType_Nearest:
+		return "near"
+	}
+	return strings.ToLower(p.Name())
+}
+
+// The resampling algorithms depending on the GDAL version, -1 if unsupported.
+var (
+	gra_Max = C.graMax()
+	gra_Min = C.graMin()
+	gra_Med = C.graMed()
+	gra_Q1  = C.graQ1()
+	gra_Q3  = C.graQ3()
+	gra_Sum = C.graSum()
+	gra_RMS = C.graRMS()
+)
+
+// warpResampleAlg returns the GDALResampleAlg of p, ok is false if it is
+// unsupported by the GDAL.
+func (p ResampleType) warpResampleAlg() (alg C.GDALResampleAlg, ok bool) {
+	switch p {
+	case ResampleType_Nil, ResampleType_Nearest:
+		return C.GRA_NearestNeighbour, true
+	case ResampleType_Bilinear:
+		return C.GRA_Bilinear, true
+	case ResampleType_Cubic:
+		return C.GRA_Cubic, true
+	case ResampleType_CubicSpline:
+		return C.GRA_CubicSpline, true
+	case ResampleType_Lanczos:
+		return C.GRA_Lanczos, true
+	case ResampleType_Average:
+		return C.GRA_Average, true
+	case ResampleType_Mode:
+		return C.GRA_Mode, true
+	case ResampleType_Max:
+		return graResampleAlg(gra_Max)
+	case ResampleType_Min:
+		return graResampleAlg(gra_Min)
+	case ResampleType_Med:
+		return graResampleAlg(gra_Med)
+	case ResampleType_Q1:
+		return graResampleAlg(gra_Q1)
+	case ResampleType_Q3:
+		return graResampleAlg(gra_Q3)
+	case ResampleType_Sum:
+		return graResampleAlg(gra_Sum)
+	case ResampleType_RMS:
+		return graResampleAlg(gra_RMS)
+	}
+	return 0, false
+}
+
+func graResampleAlg(v C.int) (alg C.GDALResampleAlg, ok bool) {
+	if v < 0 {
+		return 0, false
+	}
+	return C.GDALResampleAlg(v), true
+}
+
+func ftoa(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 ChaiShushan <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gdal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWarp_mem(t *testing.T) {
+	wgs84, err := NewSpatialReferenceFromEPSG(4326)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wgs84.Close()
+
+	wkt, err := wgs84.ExportToWkt()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := CreateDataset("", 360, 170, 1, reflect.Uint8, &Options{
+		DriverName: "MEM",
+		Projection: wkt,
+		Transform:  [6]float64{-180, 1, 0, 85, 0, -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	merc, err := NewSpatialReference("EPSG:3857")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer merc.Close()
+
+	dst, err := Warp("", src, &WarpOptions{
+		DstSRS:       merc,
+		Width:        256,
+		Height:       256,
+		ResampleType: ResampleType_Bilinear,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	if dst.Width() != 256 || dst.Height() != 256 || dst.DataType() != reflect.Uint8 {
+		t.Fatalf("bad dst: %dx%d, %v", dst.Width(), dst.Height(), dst.DataType())
+	}
+	srs, err := dst.SpatialReference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srs.Close()
+
+	if !srs.IsSame(merc) {
+		t.Fatalf("bad dst projection: %s", dst.Opt.Projection)
+	}
+}
@@ -6,13 +6,41 @@ package gdal
 
 import (
 	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"reflect"
 )
 
 // Encode writes the image m to w in GDAL format.
+//
+// The driver is opt.DriverName, "GTiff" by default.
+func Encode(w io.Writer, m image.Image, opt *Options) (err error) {
+	driverName := "GTiff"
+	if opt != nil && opt.DriverName != "" {
+		driverName = opt.DriverName
+	}
+	data, err := SaveToBytes(m, driverName, opt)
+	if err != nil {
+		return
+	}
+	_, err = w.Write(data)
+	return
+}
+
+// Save writes the image m to file in GDAL format.
+//
+// The *image.Paletted is saved as a 8-bit band with the color table, the
+// alpha of *image.RGBA and *image.RGBA64 is saved as unassociated.
 func Save(filename string, m image.Image, opt *Options) (err error) {
-	p, ok := AsMemPImage(m)
-	if !ok {
-		p = NewMemPImageFrom(m)
+	p, pal := memPImageToWrite(m)
+	if p.XNoData != nil && (opt == nil || opt.NoData == nil) {
+		var newOpt Options
+		if opt != nil {
+			newOpt = *opt
+		}
+		newOpt.NoData = p.XNoData
+		opt = &newOpt
 	}
 
 	f, err := CreateDataset(filename, p.XRect.Dx(), p.XRect.Dy(), p.XChannels, p.XDataType, opt)
@@ -21,8 +49,68 @@ func Save(filename string, m image.Image, opt *Options) (err error) {
 	}
 	defer f.Close()
 
-	if err = f.WriteFromBuf(p.XRect, p.XPix, p.XStride); err != nil {
-		return
-	}
+	err = f.writeMemPImage(p, pal)
 	return
 }
+
+// writeMemPImage writes the pixels of m to the dataset, the color table is
+// set if pal is not nil, otherwise the default color interpretations.
+func (p *Dataset) writeMemPImage(m *MemPImage, pal color.Palette) error {
+	if pal != nil {
+		if err := p.setPalette(pal); err != nil {
+			return err
+		}
+	} else {
+		p.setDefaultColorInterps()
+	}
+	return p.WriteFromBuf(m.XRect, m.XPix, m.XStride)
+}
+
+// memPImageToWrite returns the pixels of m to write and the palette of
+// *image.Paletted.
+//
+// The alpha band of GDAL is unassociated, the *image.RGBA and *image.RGBA64
+// with alpha are converted to non-premultiplied colors, the *image.NRGBA
+// and *image.NRGBA64 are written as is.
+func memPImageToWrite(m image.Image) (p *MemPImage, pal color.Palette) {
+	switch m := m.(type) {
+	case *image.Paletted:
+		return memPImageFromPaletted(m), m.Palette
+	case *image.RGBA:
+		if !m.Opaque() {
+			dst := image.NewNRGBA(m.Bounds())
+			draw.Draw(dst, dst.Rect, m, dst.Rect.Min, draw.Src)
+			return memPImageToWrite(dst)
+		}
+	case *image.RGBA64:
+		if !m.Opaque() {
+			dst := image.NewNRGBA64(m.Bounds())
+			draw.Draw(dst, dst.Rect, m, dst.Rect.Min, draw.Src)
+			return memPImageToWrite(dst)
+		}
+	case *image.NRGBA:
+		return &MemPImage{
+			XMemPMagic: MemPMagic,
+			XRect:      m.Rect,
+			XChannels:  4,
+			XDataType:  reflect.Uint8,
+			XPix:       m.Pix,
+			XStride:    m.Stride,
+		}, nil
+	case *image.NRGBA64:
+		p = NewMemPImage(m.Rect, 4, reflect.Uint16)
+		for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+			off0 := m.PixOffset(m.Rect.Min.X, y)
+			off1 := p.PixOffset(m.Rect.Min.X, y)
+			copy(p.XPix[off1:][:p.XStride], m.Pix[off0:][:p.XStride])
+		}
+		if isLittleEndian {
+			p.XPix.SwapEndian(p.XDataType)
+		}
+		return p, nil
+	}
+	if p, ok := AsMemPImage(m); ok {
+		return p, nil
+	}
+	return NewMemPImageFrom(m), nil
+}
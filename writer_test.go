@@ -3,3 +3,43 @@
 // license that can be found in the LICENSE file.
 
 package gdal
+
+import (
+	"image"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSave_noData(t *testing.T) {
+	tmpname := "z_test_TestSave_noData.tiff"
+	defer os.Remove(tmpname)
+
+	nodata := -9999.0
+	m := NewMemPImage(image.Rect(0, 0, 40, 30), 1, reflect.Float32)
+	m.XNoData = &nodata
+	if err := Save(tmpname, m, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	m2, err := LoadImage(tmpname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m2.NoData(); !ok || v != nodata {
+		t.Fatalf("expect = %v, got = %v(%v)", nodata, v, ok)
+	}
+
+	f, err := OpenDataset(tmpname, GA_Update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := f.DeleteNoDataValue(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.GetNoDataValue(); ok {
+		t.Fatal("expect no NoData value")
+	}
+}